@@ -0,0 +1,43 @@
+package main
+
+import (
+	"chip8/asm"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func runAsm(args []string) error {
+	flags := flag.NewFlagSet("asm", flag.ExitOnError)
+	output := flags.String("o", "", "output file (defaults to the source name with a .ch8 extension)")
+
+	flags.Usage = func() {
+		fmt.Printf("Usage: %s asm [OPTIONS] FILENAME\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	filename := flags.Arg(0)
+	if filename == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	rom, err := asm.AssembleFile(filename)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		*output = strings.TrimSuffix(filename, filepath.Ext(filename)) + ".ch8"
+	}
+
+	if err := ioutil.WriteFile(*output, rom, 0644); err != nil {
+		return fmt.Errorf("failed to write ROM file: %v", err)
+	}
+
+	return nil
+}
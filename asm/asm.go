@@ -0,0 +1,334 @@
+// Package asm assembles CHIP-8 programs written with the classic mnemonics
+// from Cowgod's technical reference, the syntax produced by disasm.SyntaxCowgod.
+//
+// A line holds an optional label followed by an instruction or directive:
+//
+//	loop:   LD V0, K        ; comments start with a semicolon
+//	        JP loop
+//	SPEED   EQU 4           ; constants
+//	sprite: DB #F0, %10010000, $90, 0x90, 240
+//	        DW #1234
+//	        INCLUDE "data.asm"
+//	        ORG #300
+package asm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+const defaultOrigin = 0x200
+
+// maxIncludeDepth guards against files that include themselves.
+const maxIncludeDepth = 16
+
+type position struct {
+	file   string
+	line   int
+	column int
+}
+
+type operand struct {
+	text string
+	pos  position
+}
+
+type statement struct {
+	pos      position
+	mnemonic string
+	operands []operand
+	address  int
+}
+
+type constant struct {
+	expr operand
+	busy bool
+}
+
+type Assembler struct {
+	// ReadFile loads included files. It defaults to ioutil.ReadFile.
+	ReadFile func(filename string) ([]byte, error)
+
+	statements []statement
+	labels     map[string]int
+	constants  map[string]*constant
+	errors     ErrorList
+
+	origin  int
+	address int
+	started bool
+}
+
+// Assemble assembles source, reporting errors against filename.
+func Assemble(filename string, source []byte) ([]byte, error) {
+	return (&Assembler{}).Assemble(filename, source)
+}
+
+// AssembleFile assembles the file at filename.
+func AssembleFile(filename string) ([]byte, error) {
+	source, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source file: %v", err)
+	}
+
+	return Assemble(filename, source)
+}
+
+func (a *Assembler) Assemble(filename string, source []byte) ([]byte, error) {
+	if a.ReadFile == nil {
+		a.ReadFile = ioutil.ReadFile
+	}
+
+	a.statements = nil
+	a.labels = make(map[string]int)
+	a.constants = make(map[string]*constant)
+	a.errors = nil
+	a.origin = defaultOrigin
+	a.address = defaultOrigin
+	a.started = false
+
+	a.parse(filename, source, 0)
+
+	if len(a.errors) > 0 {
+		return nil, a.errors
+	}
+
+	rom := a.emit()
+
+	if len(a.errors) > 0 {
+		return nil, a.errors
+	}
+
+	return rom, nil
+}
+
+func (a *Assembler) errorf(pos position, format string, args ...interface{}) {
+	a.errors = append(a.errors, &Error{
+		File:    pos.file,
+		Line:    pos.line,
+		Column:  pos.column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// parse runs the first pass over a file: it records labels and constants and
+// lays out every statement so that each has an address.
+func (a *Assembler) parse(filename string, source []byte, depth int) {
+	lines := strings.Split(string(source), "\n")
+
+	for n, text := range lines {
+		text = strings.TrimRight(stripComment(text), " \t\r")
+
+		s := scanner{text: text, pos: position{file: filename, line: n + 1, column: 1}}
+
+		s.skipSpace()
+		if s.done() {
+			continue
+		}
+
+		start := s.position()
+		word := s.identifier()
+
+		if word != "" && s.peek() == ':' {
+			s.next()
+			a.defineLabel(start, word)
+
+			s.skipSpace()
+			if s.done() {
+				continue
+			}
+
+			start = s.position()
+			word = s.identifier()
+		}
+
+		if word == "" {
+			a.errorf(start, "expected instruction, found %q", s.rest())
+			continue
+		}
+
+		s.skipSpace()
+		if next := s.position(); strings.EqualFold(s.peekIdentifier(), "EQU") {
+			s.identifier()
+			s.skipSpace()
+
+			expr := operand{text: s.rest(), pos: s.position()}
+			if expr.text == "" {
+				a.errorf(next, "missing value for %s", word)
+				continue
+			}
+
+			if _, ok := a.constants[word]; ok {
+				a.errorf(start, "constant %s redefined", word)
+				continue
+			}
+
+			a.constants[word] = &constant{expr: expr}
+
+			continue
+		}
+
+		st := statement{pos: start, mnemonic: strings.ToUpper(word), operands: s.operands()}
+
+		switch st.mnemonic {
+		case "INCLUDE":
+			a.include(st, filename, depth)
+		case "ORG":
+			a.org(st)
+		default:
+			st.address = a.address
+			a.address += a.size(st)
+			a.started = true
+			a.statements = append(a.statements, st)
+		}
+	}
+}
+
+func (a *Assembler) defineLabel(pos position, name string) {
+	if _, ok := a.labels[name]; ok {
+		a.errorf(pos, "label %s redefined", name)
+		return
+	}
+
+	a.labels[name] = a.address
+}
+
+func (a *Assembler) include(st statement, filename string, depth int) {
+	if len(st.operands) != 1 {
+		a.errorf(st.pos, "INCLUDE expects a file name")
+		return
+	}
+
+	name, ok := unquote(st.operands[0].text)
+	if !ok {
+		a.errorf(st.operands[0].pos, "INCLUDE expects a quoted file name")
+		return
+	}
+
+	if depth >= maxIncludeDepth {
+		a.errorf(st.pos, "includes nested too deeply")
+		return
+	}
+
+	path := filepath.Join(filepath.Dir(filename), name)
+
+	source, err := a.ReadFile(path)
+	if err != nil {
+		a.errorf(st.operands[0].pos, "failed to include %s: %v", name, err)
+		return
+	}
+
+	a.parse(path, source, depth+1)
+}
+
+func (a *Assembler) org(st statement) {
+	if len(st.operands) != 1 {
+		a.errorf(st.pos, "ORG expects an address")
+		return
+	}
+
+	addr, ok := a.eval(st.operands[0])
+	if !ok {
+		return
+	}
+
+	if !a.started {
+		a.origin = addr
+	} else if addr < a.origin {
+		a.errorf(st.operands[0].pos, "ORG #%X is before the start of the program at #%X", addr, a.origin)
+		return
+	}
+
+	a.address = addr
+}
+
+func (a *Assembler) size(st statement) int {
+	switch st.mnemonic {
+	case "DB":
+		n := 0
+		for _, op := range st.operands {
+			if s, ok := unquote(op.text); ok {
+				n += len(s)
+			} else {
+				n++
+			}
+		}
+
+		return n
+	case "DW":
+		return 2 * len(st.operands)
+	case "LDL":
+		return 4
+	}
+
+	return 2
+}
+
+// emit runs the second pass, encoding every statement now that all labels
+// are known.
+func (a *Assembler) emit() []byte {
+	end := a.origin
+	for _, st := range a.statements {
+		if last := st.address + a.size(st); last > end {
+			end = last
+		}
+	}
+
+	rom := make([]byte, end-a.origin)
+
+	for _, st := range a.statements {
+		b := a.encode(st)
+		copy(rom[st.address-a.origin:], b)
+	}
+
+	return rom
+}
+
+func (a *Assembler) lookup(name string, pos position) (int, bool) {
+	if addr, ok := a.labels[name]; ok {
+		return addr, true
+	}
+
+	c, ok := a.constants[name]
+	if !ok {
+		a.errorf(pos, "undefined symbol %s", name)
+		return 0, false
+	}
+
+	if c.busy {
+		a.errorf(pos, "constant %s refers to itself", name)
+		return 0, false
+	}
+
+	c.busy = true
+	defer func() { c.busy = false }()
+
+	return a.eval(c.expr)
+}
+
+func stripComment(line string) string {
+	quoted := false
+
+	for i := 0; i < len(line); i++ {
+		switch {
+		case !quoted && isCharLiteral(line[i:]):
+			i += 2
+		case line[i] == '"':
+			quoted = !quoted
+		case line[i] == ';' && !quoted:
+			return line[:i]
+		}
+	}
+
+	return line
+}
+
+func unquote(text string) (string, bool) {
+	if len(text) >= 2 && text[0] == '"' && text[len(text)-1] == '"' {
+		return text[1 : len(text)-1], true
+	}
+
+	return "", false
+}
@@ -0,0 +1,192 @@
+package asm_test
+
+import (
+	"chip8/asm"
+	"chip8/chip8/opcodes"
+	"chip8/disasm"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type AssembleSuite struct {
+	suite.Suite
+}
+
+func (suite *AssembleSuite) assemble(source string) []byte {
+	rom, err := asm.Assemble("test.asm", []byte(source))
+	suite.Require().Nil(err)
+
+	return rom
+}
+
+func (suite *AssembleSuite) TestRoundTripInstructions() {
+	for i := 0; i <= 0xFFFF; i++ {
+		op := opcodes.Opcode(i)
+
+		text, ok := disasm.Format(op, 0x1234, disasm.SyntaxCowgod)
+		if !ok {
+			continue
+		}
+
+		expected := []byte{byte(op >> 8), byte(op)}
+		if op.Instruction() == opcodes.InstructionF000 {
+			expected = append(expected, 0x12, 0x34)
+		}
+
+		rom, err := asm.Assemble("test.asm", []byte("\t"+text))
+		if !suite.Assert().Nil(err, text) || !suite.Assert().Equal(expected, rom, text) {
+			return
+		}
+	}
+}
+
+func (suite *AssembleSuite) TestRoundTripListing() {
+	program := []byte{
+		0x00, 0xE0, // 200: CLS
+		0xA2, 0x10, // 202: LD I, sprite
+		0x22, 0x0C, // 204: CALL draw
+		0x3F, 0x01, // 206: SE VF, #01
+		0x12, 0x06, // 208: JP #206
+		0x12, 0x00, // 20A: JP #200
+		0xD0, 0x15, // 20C: DRW V0, V1, 5
+		0x00, 0xEE, // 20E: RET
+		0xF0, 0x90, 0x90, 0x90, 0xF0, // 210: sprite
+	}
+
+	for _, origin := range []uint16{0x200, 0x600} {
+		rom := make([]byte, len(program))
+		copy(rom, program)
+
+		// Relocate the absolute addresses in the program to the origin.
+		for _, i := range []int{2, 4, 8, 10} {
+			rom[i] += byte((origin - 0x200) >> 8)
+		}
+
+		listing := disasm.Disassemble(rom, disasm.Options{Syntax: disasm.SyntaxCowgod, Origin: origin})
+
+		var b strings.Builder
+		suite.Require().Nil(listing.Fprint(&b, false))
+
+		suite.Assert().Equal(rom, suite.assemble(b.String()))
+	}
+}
+
+func (suite *AssembleSuite) TestLabelsAndConstants() {
+	rom := suite.assemble("" +
+		"SPEED   EQU 4\n" +
+		"NEXT    EQU end + 2\n" +
+		"start:  LD V0, SPEED ; comment\n" +
+		"        LD I, NEXT\n" +
+		"loop:   JP loop\n" +
+		"        JP start\n" +
+		"end:    JP V0, end - 2\n")
+
+	suite.Assert().Equal([]byte{
+		0x60, 0x04,
+		0xA2, 0x0A,
+		0x12, 0x04,
+		0x12, 0x00,
+		0xB2, 0x06,
+	}, rom)
+}
+
+func (suite *AssembleSuite) TestNumbers() {
+	rom := suite.assemble("\tDB 10, #0A, $0A, 0x0A, %1010, 0b1010, 'A', -1\n")
+
+	suite.Assert().Equal([]byte{10, 10, 10, 10, 10, 10, 'A', 0xFF}, rom)
+}
+
+func (suite *AssembleSuite) TestCharLiterals() {
+	rom := suite.assemble("\tDB ';', 1, ',', '\"' ; comment\n")
+
+	suite.Assert().Equal([]byte{';', 1, ',', '"'}, rom)
+}
+
+func (suite *AssembleSuite) TestData() {
+	rom := suite.assemble("" +
+		"\tDB \"HI;\", 0\n" +
+		"\tDW #1234, label\n" +
+		"label:\n")
+
+	suite.Assert().Equal([]byte{'H', 'I', ';', 0, 0x12, 0x34, 0x02, 0x08}, rom)
+}
+
+func (suite *AssembleSuite) TestOrg() {
+	rom := suite.assemble("" +
+		"\tORG #600\n" +
+		"start:\tJP start\n" +
+		"\tORG #606\n" +
+		"\tDB 1\n")
+
+	suite.Assert().Equal([]byte{0x16, 0x00, 0, 0, 0, 0, 1}, rom)
+}
+
+func (suite *AssembleSuite) TestShiftSingleOperand() {
+	rom := suite.assemble("\tSHR V3\n\tSHL V4\n")
+
+	suite.Assert().Equal([]byte{0x83, 0x36, 0x84, 0x4E}, rom)
+}
+
+func (suite *AssembleSuite) TestInclude() {
+	files := map[string]string{
+		"lib/font.asm":   "\tINCLUDE \"digits.asm\"\n",
+		"lib/digits.asm": "zero:\tDB #F0\n",
+	}
+
+	a := &asm.Assembler{
+		ReadFile: func(filename string) ([]byte, error) {
+			source, ok := files[filename]
+			if !ok {
+				return nil, os.ErrNotExist
+			}
+
+			return []byte(source), nil
+		},
+	}
+
+	rom, err := a.Assemble("main.asm", []byte("\tLD I, zero\n\tINCLUDE \"lib/font.asm\"\n"))
+	suite.Require().Nil(err)
+
+	suite.Assert().Equal([]byte{0xA2, 0x02, 0xF0}, rom)
+}
+
+func (suite *AssembleSuite) TestErrors() {
+	tests := []struct {
+		source   string
+		expected string
+	}{
+		{"\tJP nowhere\n", "test.asm:1:5: undefined symbol nowhere"},
+		{"\n  FOO V0\n", "test.asm:2:3: unknown instruction FOO"},
+		{"\tLD V0, 256\n", "test.asm:1:9: value 256 out of range -128 to 255"},
+		{"\tDRW V0, V1, 16\n", "test.asm:1:14: value 16 out of range 0 to 15"},
+		{"\tLD DT, 5\n", "test.asm:1:2: invalid operands for LD"},
+		{"a:\na:\n", "test.asm:2:1: label a redefined"},
+		{"\tLD V0, #1G\n", "test.asm:1:9: invalid number \"#1G\""},
+		{"X EQU Y\nY EQU X\n\tDB X\n", "test.asm:2:7: constant X refers to itself"},
+		{"\tINCLUDE \"missing.asm\"\n", "test.asm:1:10: failed to include missing.asm"},
+	}
+
+	for _, test := range tests {
+		_, err := asm.Assemble("test.asm", []byte(test.source))
+		if suite.Assert().Error(err, test.source) {
+			suite.Assert().Contains(err.Error(), test.expected)
+		}
+	}
+}
+
+func (suite *AssembleSuite) TestErrorList() {
+	_, err := asm.Assemble("test.asm", []byte("\tJP a\n\tJP b\n"))
+
+	list, ok := err.(asm.ErrorList)
+	suite.Require().True(ok)
+	suite.Assert().Len(list, 2)
+	suite.Assert().Equal(fmt.Sprintf("%s\n%s", list[0], list[1]), err.Error())
+}
+
+func TestAssembleSuite(t *testing.T) {
+	suite.Run(t, new(AssembleSuite))
+}
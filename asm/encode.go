@@ -0,0 +1,226 @@
+package asm
+
+import (
+	"strings"
+)
+
+// form is one way of writing an instruction. Each operand in the pattern is
+// either a keyword that must appear literally, or one of:
+//
+//	x, y  a register placed in the X or Y nibble
+//	xy    a register placed in both the X and Y nibbles
+//	n     a 4-bit value in the low nibble
+//	xn    a 4-bit value in the X nibble
+//	nn    an 8-bit value in the low byte
+//	nnn   a 12-bit address
+//	long  a 16-bit address in the word following the instruction
+type form struct {
+	operands string
+	opcode   uint16
+}
+
+var instructions = map[string][]form{
+	"CLS":   {{"", 0x00E0}},
+	"RET":   {{"", 0x00EE}},
+	"SCD":   {{"n", 0x00C0}},
+	"SCU":   {{"n", 0x00D0}},
+	"SCR":   {{"", 0x00FB}},
+	"SCL":   {{"", 0x00FC}},
+	"EXIT":  {{"", 0x00FD}},
+	"LOW":   {{"", 0x00FE}},
+	"HIGH":  {{"", 0x00FF}},
+	"JP":    {{"nnn", 0x1000}, {"V0,nnn", 0xB000}},
+	"CALL":  {{"nnn", 0x2000}},
+	"SE":    {{"x,y", 0x5000}, {"x,nn", 0x3000}},
+	"SNE":   {{"x,y", 0x9000}, {"x,nn", 0x4000}},
+	"SAVE":  {{"x,y", 0x5002}},
+	"LOAD":  {{"x,y", 0x5003}},
+	"OR":    {{"x,y", 0x8001}},
+	"AND":   {{"x,y", 0x8002}},
+	"XOR":   {{"x,y", 0x8003}},
+	"SUB":   {{"x,y", 0x8005}},
+	"SHR":   {{"x,y", 0x8006}, {"xy", 0x8006}},
+	"SUBN":  {{"x,y", 0x8007}},
+	"SHL":   {{"x,y", 0x800E}, {"xy", 0x800E}},
+	"RND":   {{"x,nn", 0xC000}},
+	"DRW":   {{"x,y,n", 0xD000}},
+	"SKP":   {{"x", 0xE09E}},
+	"SKNP":  {{"x", 0xE0A1}},
+	"LDL":   {{"I,long", 0xF000}},
+	"PLANE": {{"xn", 0xF001}},
+	"AUDIO": {{"", 0xF002}},
+	"PITCH": {{"x", 0xF03A}},
+	"ADD": {
+		{"x,y", 0x8004},
+		{"x,nn", 0x7000},
+		{"I,x", 0xF01E},
+	},
+	"LD": {
+		{"x,y", 0x8000},
+		{"x,nn", 0x6000},
+		{"I,nnn", 0xA000},
+		{"x,DT", 0xF007},
+		{"x,K", 0xF00A},
+		{"DT,x", 0xF015},
+		{"ST,x", 0xF018},
+		{"F,x", 0xF029},
+		{"HF,x", 0xF030},
+		{"B,x", 0xF033},
+		{"[I],x", 0xF055},
+		{"x,[I]", 0xF065},
+		{"R,x", 0xF075},
+		{"x,R", 0xF085},
+	},
+}
+
+var keywords = map[string]bool{
+	"I": true, "[I]": true, "DT": true, "ST": true, "K": true, "F": true, "HF": true, "B": true, "R": true,
+}
+
+func register(text string) (int, bool) {
+	if len(text) != 2 || (text[0] != 'V' && text[0] != 'v') {
+		return 0, false
+	}
+
+	i := strings.IndexByte("0123456789ABCDEF", strings.ToUpper(text)[1])
+
+	return i, i >= 0
+}
+
+// matches reports whether the operands have the shape described by pattern.
+func matches(pattern string, operands []operand) bool {
+	var fields []string
+	if pattern != "" {
+		fields = strings.Split(pattern, ",")
+	}
+
+	if len(fields) != len(operands) {
+		return false
+	}
+
+	for i, field := range fields {
+		text := strings.ToUpper(operands[i].text)
+		_, isRegister := register(text)
+
+		switch field {
+		case "x", "y", "xy":
+			if !isRegister {
+				return false
+			}
+		case "n", "xn", "nn", "nnn", "long":
+			if isRegister || keywords[text] {
+				return false
+			}
+		default:
+			if text != field {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (a *Assembler) encode(st statement) []byte {
+	switch st.mnemonic {
+	case "DB":
+		return a.data(st, 1)
+	case "DW":
+		return a.data(st, 2)
+	}
+
+	forms, ok := instructions[st.mnemonic]
+	if !ok {
+		a.errorf(st.pos, "unknown instruction %s", st.mnemonic)
+		return nil
+	}
+
+	for _, f := range forms {
+		if matches(f.operands, st.operands) {
+			return a.assemble(f, st)
+		}
+	}
+
+	a.errorf(st.pos, "invalid operands for %s", st.mnemonic)
+
+	return nil
+}
+
+func (a *Assembler) assemble(f form, st statement) []byte {
+	op := f.opcode
+	var long uint16
+
+	var fields []string
+	if f.operands != "" {
+		fields = strings.Split(f.operands, ",")
+	}
+
+	for i, field := range fields {
+		arg := st.operands[i]
+
+		switch field {
+		case "x":
+			x, _ := register(arg.text)
+			op |= uint16(x) << 8
+		case "xy":
+			x, _ := register(arg.text)
+			op |= uint16(x)<<8 | uint16(x)<<4
+		case "y":
+			y, _ := register(arg.text)
+			op |= uint16(y) << 4
+		case "n":
+			op |= uint16(a.value(arg, 0, 0xF))
+		case "xn":
+			op |= uint16(a.value(arg, 0, 0xF)) << 8
+		case "nn":
+			op |= uint16(a.value(arg, -0x80, 0xFF)) & 0xFF
+		case "nnn":
+			op |= uint16(a.value(arg, 0, 0xFFF))
+		case "long":
+			long = uint16(a.value(arg, 0, 0xFFFF))
+		}
+	}
+
+	if f.opcode == 0xF000 {
+		return []byte{byte(op >> 8), byte(op), byte(long >> 8), byte(long)}
+	}
+
+	return []byte{byte(op >> 8), byte(op)}
+}
+
+// data encodes the operands of DB or DW as size byte big-endian values.
+// Strings are only allowed in DB.
+func (a *Assembler) data(st statement, size int) []byte {
+	var b []byte
+
+	for _, arg := range st.operands {
+		if s, ok := unquote(arg.text); ok && size == 1 {
+			b = append(b, s...)
+			continue
+		}
+
+		if size == 1 {
+			b = append(b, byte(a.value(arg, -0x80, 0xFF)))
+		} else {
+			v := a.value(arg, -0x8000, 0xFFFF)
+			b = append(b, byte(v>>8), byte(v))
+		}
+	}
+
+	return b
+}
+
+// value evaluates arg and checks that it lies within [min, max].
+func (a *Assembler) value(arg operand, min, max int) int {
+	v, ok := a.eval(arg)
+	if !ok {
+		return 0
+	}
+
+	if v < min || v > max {
+		a.errorf(arg.pos, "value %d out of range %d to %d", v, min, max)
+		return 0
+	}
+
+	return v
+}
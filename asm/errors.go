@@ -0,0 +1,30 @@
+package asm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error is a problem found in the source, reported as file:line:column.
+type Error struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+}
+
+// ErrorList holds every error found while assembling.
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+	messages := make([]string, len(l))
+	for i, e := range l {
+		messages[i] = e.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
@@ -0,0 +1,109 @@
+package asm
+
+import (
+	"strconv"
+	"strings"
+)
+
+// eval evaluates an expression made of numbers, character literals, labels
+// and constants joined by + and -.
+func (a *Assembler) eval(op operand) (int, bool) {
+	s := scanner{text: op.text, pos: op.pos}
+
+	total := 0
+	sign := 1
+
+	s.skipSpace()
+	if c := s.peek(); c == '-' || c == '+' {
+		if c == '-' {
+			sign = -1
+		}
+
+		s.next()
+	}
+
+	for {
+		s.skipSpace()
+
+		v, ok := a.term(&s)
+		if !ok {
+			return 0, false
+		}
+
+		total += sign * v
+
+		s.skipSpace()
+		if s.done() {
+			return total, true
+		}
+
+		switch s.peek() {
+		case '+':
+			sign = 1
+		case '-':
+			sign = -1
+		default:
+			a.errorf(s.position(), "unexpected %q in expression", s.rest())
+			return 0, false
+		}
+
+		s.next()
+	}
+}
+
+func (a *Assembler) term(s *scanner) (int, bool) {
+	pos := s.position()
+	rest := s.rest()
+
+	if identifierLength(rest) > 0 {
+		return a.lookup(s.identifier(), pos)
+	}
+
+	if isCharLiteral(rest) {
+		s.i += 3
+		return int(rest[1]), true
+	}
+
+	n := 0
+	for n < len(rest) && (isIdentPart(rest[n]) || (n == 0 && strings.IndexByte("#$%", rest[0]) >= 0)) {
+		n++
+	}
+
+	text := rest[:n]
+	s.i += n
+
+	v, ok := parseNumber(text)
+	if !ok {
+		if text == "" {
+			a.errorf(pos, "expected value")
+		} else {
+			a.errorf(pos, "invalid number %q", text)
+		}
+
+		return 0, false
+	}
+
+	return v, true
+}
+
+func parseNumber(text string) (int, bool) {
+	base := 10
+
+	switch {
+	case strings.HasPrefix(text, "#"), strings.HasPrefix(text, "$"):
+		text, base = text[1:], 16
+	case strings.HasPrefix(text, "%"):
+		text, base = text[1:], 2
+	case strings.HasPrefix(text, "0x"), strings.HasPrefix(text, "0X"):
+		text, base = text[2:], 16
+	case strings.HasPrefix(text, "0b"), strings.HasPrefix(text, "0B"):
+		text, base = text[2:], 2
+	}
+
+	v, err := strconv.ParseUint(text, base, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	return int(v), true
+}
@@ -0,0 +1,125 @@
+package asm
+
+import (
+	"strings"
+)
+
+// scanner walks a single line of source, keeping track of the column.
+type scanner struct {
+	text string
+	i    int
+	pos  position
+}
+
+func (s *scanner) done() bool {
+	return s.i >= len(s.text)
+}
+
+func (s *scanner) peek() byte {
+	if s.done() {
+		return 0
+	}
+
+	return s.text[s.i]
+}
+
+func (s *scanner) next() {
+	s.i++
+}
+
+func (s *scanner) rest() string {
+	return s.text[s.i:]
+}
+
+func (s *scanner) position() position {
+	pos := s.pos
+	pos.column += s.i
+
+	return pos
+}
+
+func (s *scanner) skipSpace() {
+	for !s.done() && (s.peek() == ' ' || s.peek() == '\t') {
+		s.next()
+	}
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+// isCharLiteral reports whether text starts with a character literal such as
+// 'A'.
+func isCharLiteral(text string) bool {
+	return len(text) >= 3 && text[0] == '\'' && text[2] == '\''
+}
+
+func identifierLength(text string) int {
+	if len(text) == 0 || !isIdentStart(text[0]) {
+		return 0
+	}
+
+	n := 1
+	for n < len(text) && isIdentPart(text[n]) {
+		n++
+	}
+
+	return n
+}
+
+func (s *scanner) peekIdentifier() string {
+	return s.text[s.i : s.i+identifierLength(s.rest())]
+}
+
+func (s *scanner) identifier() string {
+	word := s.peekIdentifier()
+	s.i += len(word)
+
+	return word
+}
+
+// operands splits the rest of the line on commas that are not inside quotes
+// or character literals.
+func (s *scanner) operands() []operand {
+	var operands []operand
+
+	s.skipSpace()
+	if s.done() {
+		return nil
+	}
+
+	for {
+		s.skipSpace()
+		start := s.position()
+		from := s.i
+
+		quoted := false
+		for !s.done() && (quoted || s.peek() != ',') {
+			if !quoted && isCharLiteral(s.rest()) {
+				s.i += 3
+				continue
+			}
+
+			if s.peek() == '"' {
+				quoted = !quoted
+			}
+
+			s.next()
+		}
+
+		operands = append(operands, operand{
+			text: strings.TrimRight(s.text[from:s.i], " \t"),
+			pos:  start,
+		})
+
+		if s.done() {
+			return operands
+		}
+
+		s.next()
+	}
+}
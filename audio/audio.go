@@ -0,0 +1,196 @@
+// Package audio synthesises the sound a Chip8 makes: a tone while the sound
+// timer runs, or the XO-CHIP audio pattern once a program has loaded one.
+package audio
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+)
+
+type Waveform int
+
+const (
+	WaveformSine Waveform = iota
+	WaveformSquare
+	WaveformTriangle
+)
+
+var waveforms = map[string]Waveform{
+	"sine":     WaveformSine,
+	"square":   WaveformSquare,
+	"triangle": WaveformTriangle,
+}
+
+func ParseWaveform(name string) (Waveform, error) {
+	w, ok := waveforms[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown waveform %q, expected sine, square or triangle", name)
+	}
+
+	return w, nil
+}
+
+// sample returns the waveform's value, from -1 to 1, at phase from 0 to 1.
+func (w Waveform) sample(phase float64) float64 {
+	switch w {
+	case WaveformSquare:
+		if phase < 0.5 {
+			return 1
+		}
+
+		return -1
+	case WaveformTriangle:
+		return 1 - 4*math.Abs(phase-0.5)
+	default:
+		return math.Sin(2 * math.Pi * phase)
+	}
+}
+
+type Options struct {
+	// Frequency is the pitch of the tone in Hz.
+	Frequency float64
+	Waveform  Waveform
+	// Volume is from 0 to 1.
+	Volume float64
+}
+
+// DefaultOptions play a 440 Hz sine wave at half volume.
+var DefaultOptions = Options{
+	Frequency: 440,
+	Waveform:  WaveformSine,
+	Volume:    0.5,
+}
+
+const patternBits = 128
+
+// DefaultSampleRate is the sample rate the frontends play and record at.
+const DefaultSampleRate = 22050
+
+// framesPerSecond is the rate the sound state is reported at.
+const framesPerSecond = 60
+
+// Synth generates unsigned 8-bit mono samples. It implements
+// chip8.PatternBeeper, and is safe to read from an audio callback while the
+// emulator updates it.
+type Synth struct {
+	mu sync.Mutex
+
+	sampleRate float64
+	options    Options
+
+	on    bool
+	phase float64
+
+	patternEnabled bool
+	pattern        [16]uint8
+	rate           float64
+	position       float64
+}
+
+func NewSynth(sampleRate int, options Options) *Synth {
+	return &Synth{sampleRate: float64(sampleRate), options: options}
+}
+
+func (s *Synth) SetSound(on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.on = on
+}
+
+// SetPattern replaces the tone with the pattern, played back at
+// 4000*2^((pitch-64)/48) bits per second.
+func (s *Synth) SetPattern(pattern [16]uint8, pitch uint8) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.patternEnabled = true
+	s.pattern = pattern
+	s.rate = 4000 * math.Pow(2, (float64(pitch)-64)/48)
+}
+
+// Read fills buf with the next samples, silence while the sound is off.
+func (s *Synth) Read(buf []uint8) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range buf {
+		if !s.on {
+			buf[i] = 0x80
+			continue
+		}
+
+		var v float64
+		if s.patternEnabled {
+			bit := int(s.position) % patternBits
+
+			v = -1
+			if s.pattern[bit/8]>>(7-bit%8)&1 != 0 {
+				v = 1
+			}
+
+			s.position = math.Mod(s.position+s.rate/s.sampleRate, patternBits)
+		} else {
+			v = s.options.Waveform.sample(s.phase)
+			s.phase = math.Mod(s.phase+s.options.Frequency/s.sampleRate, 1)
+		}
+
+		buf[i] = uint8(128 + math.Round(v*s.options.Volume*127))
+	}
+}
+
+// Recorder renders a frame of samples each time the sound state is reported,
+// so a recording depends only on the emulated frames and not on an audio
+// device. It implements chip8.PatternBeeper.
+type Recorder struct {
+	synth      *Synth
+	w          io.Writer
+	sampleRate int
+
+	frames int
+	buf    []uint8
+	err    error
+}
+
+// NewRecorder writes unsigned 8-bit samples at sampleRate to w.
+func NewRecorder(w io.Writer, sampleRate int, options Options) *Recorder {
+	return &Recorder{
+		synth:      NewSynth(sampleRate, options),
+		w:          w,
+		sampleRate: sampleRate,
+	}
+}
+
+func (r *Recorder) SetSound(on bool) {
+	if r.err != nil {
+		return
+	}
+
+	// Spread the samples so every second holds exactly sampleRate of them.
+	n := (r.frames+1)*r.sampleRate/framesPerSecond - r.frames*r.sampleRate/framesPerSecond
+	r.frames++
+
+	if cap(r.buf) < n {
+		r.buf = make([]uint8, n)
+	}
+
+	r.synth.SetSound(on)
+	r.synth.Read(r.buf[:n])
+
+	if _, err := r.w.Write(r.buf[:n]); err != nil {
+		r.err = fmt.Errorf("failed to write audio: %v", err)
+	}
+}
+
+func (r *Recorder) SetPattern(pattern [16]uint8, pitch uint8) {
+	r.synth.SetPattern(pattern, pitch)
+}
+
+// Err returns the first error writing the recording. Nothing more is written
+// after an error.
+func (r *Recorder) Err() error {
+	return r.err
+}
@@ -0,0 +1,89 @@
+package audio_test
+
+import (
+	"bytes"
+	"chip8/audio"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SynthSuite struct {
+	suite.Suite
+}
+
+func (suite *SynthSuite) TestSilentWhenOff() {
+	s := audio.NewSynth(8000, audio.DefaultOptions)
+
+	buf := make([]uint8, 16)
+	s.Read(buf)
+
+	for _, b := range buf {
+		suite.Assert().Equal(uint8(0x80), b)
+	}
+}
+
+func (suite *SynthSuite) TestWaveforms() {
+	for name, expected := range map[string][]uint8{
+		"square":   {255, 255, 1, 1, 255, 255, 1, 1},
+		"triangle": {1, 128, 255, 128, 1, 128, 255, 128},
+		"sine":     {128, 255, 128, 1, 128, 255, 128, 1},
+	} {
+		w, err := audio.ParseWaveform(name)
+		suite.Require().Nil(err)
+
+		// A 2 kHz tone at 8 kHz takes four samples per cycle.
+		s := audio.NewSynth(8000, audio.Options{Frequency: 2000, Waveform: w, Volume: 1})
+		s.SetSound(true)
+
+		buf := make([]uint8, 8)
+		s.Read(buf)
+		suite.Assert().Equal(expected, buf, name)
+	}
+
+	_, err := audio.ParseWaveform("sawtooth")
+	suite.Assert().Error(err)
+}
+
+func (suite *SynthSuite) TestVolume() {
+	s := audio.NewSynth(8000, audio.Options{Frequency: 2000, Waveform: audio.WaveformSquare, Volume: 0.5})
+	s.SetSound(true)
+
+	buf := make([]uint8, 4)
+	s.Read(buf)
+	suite.Assert().Equal([]uint8{192, 192, 64, 64}, buf)
+}
+
+func (suite *SynthSuite) TestPattern() {
+	s := audio.NewSynth(4000, audio.Options{Frequency: 440, Volume: 1})
+	s.SetSound(true)
+
+	// Pitch 64 plays one bit per sample at 4 kHz.
+	s.SetPattern([16]uint8{0xA0}, 64)
+
+	buf := make([]uint8, 4)
+	s.Read(buf)
+	suite.Assert().Equal([]uint8{255, 1, 255, 1}, buf)
+
+	s.SetSound(false)
+	s.Read(buf)
+	suite.Assert().Equal([]uint8{0x80, 0x80, 0x80, 0x80}, buf)
+}
+
+func (suite *SynthSuite) TestRecorder() {
+	var b bytes.Buffer
+
+	r := audio.NewRecorder(&b, 150, audio.Options{Frequency: 75, Waveform: audio.WaveformSquare, Volume: 1})
+
+	// 150 samples per second is two and a half per frame.
+	r.SetSound(true)
+	r.SetSound(true)
+	r.SetSound(false)
+	suite.Require().Nil(r.Err())
+
+	suite.Assert().Equal([]uint8{255, 1, 255, 1, 255, 0x80, 0x80}, b.Bytes())
+}
+
+func TestSynthSuite(t *testing.T) {
+	suite.Run(t, new(SynthSuite))
+}
@@ -0,0 +1,73 @@
+// Package capture records the frames a machine draws, 60 a second, as an
+// animated GIF or as numbered PNG files for video tools.
+package capture
+
+import (
+	"chip8/chip8/display"
+)
+
+// Encoder is given the framebuffer once for every 60 Hz frame. Close finishes
+// the recording; it does not close the underlying writer.
+type Encoder interface {
+	Frame(pixels [][]uint8) error
+	Close() error
+}
+
+// Drawer passes the framebuffer on to another display.Drawer, and hands the
+// latest one to an Encoder each time EndFrame is called. Frames in which
+// nothing is drawn repeat the previous one.
+type Drawer struct {
+	next    display.Drawer
+	encoder Encoder
+
+	pixels [][]uint8
+}
+
+func NewDrawer(next display.Drawer, encoder Encoder) *Drawer {
+	return &Drawer{
+		next:    next,
+		encoder: encoder,
+
+		pixels: resize(nil, display.LowResWidth, display.LowResHeight),
+	}
+}
+
+func (d *Drawer) Draw(pixels [][]uint8) error {
+	d.pixels = copyPixels(pixels)
+
+	return d.next.Draw(pixels)
+}
+
+// EndFrame records the framebuffer as it is at the end of a frame.
+func (d *Drawer) EndFrame() error {
+	return d.encoder.Frame(d.pixels)
+}
+
+func copyPixels(pixels [][]uint8) [][]uint8 {
+	c := make([][]uint8, len(pixels))
+	for y := range pixels {
+		c[y] = append([]uint8(nil), pixels[y]...)
+	}
+
+	return c
+}
+
+// resize stretches pixels to width by height, so that low and high
+// resolution frames can share an image size. Missing pixels are blank.
+func resize(pixels [][]uint8, width, height int) [][]uint8 {
+	resized := make([][]uint8, height)
+	for y := range resized {
+		resized[y] = make([]uint8, width)
+
+		if len(pixels) == 0 {
+			continue
+		}
+
+		row := pixels[y*len(pixels)/height]
+		for x := range resized[y] {
+			resized[y][x] = row[x*len(row)/width]
+		}
+	}
+
+	return resized
+}
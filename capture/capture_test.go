@@ -0,0 +1,121 @@
+package capture_test
+
+import (
+	"bytes"
+	"chip8/capture"
+	"chip8/chip8/display"
+	"chip8/screenshot"
+	"image/gif"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockDrawer struct {
+	mock.Mock
+}
+
+func (m *MockDrawer) Draw(pixels [][]uint8) error {
+	args := m.Called(pixels)
+	return args.Error(0)
+}
+
+func blank(width, height int) [][]uint8 {
+	pixels := make([][]uint8, height)
+	for y := range pixels {
+		pixels[y] = make([]uint8, width)
+	}
+
+	return pixels
+}
+
+type CaptureSuite struct {
+	suite.Suite
+}
+
+func (suite *CaptureSuite) TestGIF() {
+	var b bytes.Buffer
+	g := capture.NewGIF(&b, screenshot.Options{Scale: 2})
+
+	drawer := new(MockDrawer)
+	drawer.On("Draw", mock.Anything).Return(nil)
+
+	d := capture.NewDrawer(drawer, g)
+
+	// Three blank frames, then two with a pixel set.
+	for i := 0; i < 3; i++ {
+		suite.Require().Nil(d.EndFrame())
+	}
+
+	pixels := blank(display.LowResWidth, display.LowResHeight)
+	pixels[0][0] = 1
+	suite.Require().Nil(d.Draw(pixels))
+
+	for i := 0; i < 2; i++ {
+		suite.Require().Nil(d.EndFrame())
+	}
+
+	suite.Require().Nil(g.Close())
+	drawer.AssertExpectations(suite.T())
+
+	anim, err := gif.DecodeAll(&b)
+	suite.Require().Nil(err)
+	suite.Require().Len(anim.Image, 2)
+
+	// Frames 0-2 last until 5/100 s, and frames 3-4 until 8/100 s.
+	suite.Assert().Equal([]int{5, 3}, anim.Delay)
+	suite.Assert().Equal(2*display.LowResWidth, anim.Config.Width)
+	suite.Assert().Equal(uint8(0), anim.Image[0].ColorIndexAt(0, 0))
+	suite.Assert().Equal(uint8(1), anim.Image[1].ColorIndexAt(1, 1))
+	suite.Assert().Equal(uint8(0), anim.Image[1].ColorIndexAt(2, 0))
+}
+
+func (suite *CaptureSuite) TestGIFResolutionChange() {
+	var b bytes.Buffer
+	g := capture.NewGIF(&b, screenshot.Options{})
+
+	low := blank(display.LowResWidth, display.LowResHeight)
+	low[0][0] = 1
+	suite.Require().Nil(g.Frame(low))
+	suite.Require().Nil(g.Frame(blank(display.HighResWidth, display.HighResHeight)))
+	suite.Require().Nil(g.Close())
+
+	anim, err := gif.DecodeAll(&b)
+	suite.Require().Nil(err)
+	suite.Assert().Equal(display.HighResWidth, anim.Config.Width)
+
+	// The low resolution frame is doubled to fill the image.
+	suite.Assert().Equal(uint8(1), anim.Image[0].ColorIndexAt(1, 1))
+	suite.Assert().Equal(uint8(0), anim.Image[0].ColorIndexAt(2, 2))
+}
+
+func (suite *CaptureSuite) TestFrames() {
+	dir, err := ioutil.TempDir("", "frames")
+	suite.Require().Nil(err)
+	defer os.RemoveAll(dir)
+
+	f, err := capture.NewFrames(dir, screenshot.Options{})
+	suite.Require().Nil(err)
+
+	suite.Require().Nil(f.Frame(blank(display.LowResWidth, display.LowResHeight)))
+	suite.Require().Nil(f.Frame(blank(display.HighResWidth, display.HighResHeight)))
+	suite.Require().Nil(f.Close())
+
+	for n := 0; n < 2; n++ {
+		file, err := os.Open(f.Filename(n))
+		suite.Require().Nil(err)
+
+		img, err := png.Decode(file)
+		file.Close()
+		suite.Require().Nil(err)
+		suite.Assert().Equal(display.HighResWidth, img.Bounds().Dx())
+	}
+}
+
+func TestCaptureSuite(t *testing.T) {
+	suite.Run(t, new(CaptureSuite))
+}
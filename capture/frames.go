@@ -0,0 +1,56 @@
+package capture
+
+import (
+	"chip8/chip8/display"
+	"chip8/screenshot"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Frames writes every frame to its own PNG in a directory, named
+// frame000000.png, frame000001.png and so on, for assembling into a video at
+// 60 fps. Every image is the size of the high resolution screen at
+// options.Scale, so the size doesn't change if the program switches
+// resolution.
+type Frames struct {
+	dir     string
+	options screenshot.Options
+
+	count int
+}
+
+// NewFrames creates dir if it doesn't exist.
+func NewFrames(dir string, options screenshot.Options) (*Frames, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create frame directory: %v", err)
+	}
+
+	return &Frames{dir: dir, options: options}, nil
+}
+
+// Filename returns the path of the nth frame.
+func (f *Frames) Filename(n int) string {
+	return filepath.Join(f.dir, fmt.Sprintf("frame%06d.png", n))
+}
+
+func (f *Frames) Frame(pixels [][]uint8) error {
+	file, err := os.Create(f.Filename(f.count))
+	if err != nil {
+		return fmt.Errorf("failed to create frame: %v", err)
+	}
+
+	pixels = resize(pixels, display.HighResWidth, display.HighResHeight)
+	if err := screenshot.Encode(file, pixels, f.options); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	f.count++
+
+	return file.Close()
+}
+
+func (f *Frames) Close() error {
+	return nil
+}
@@ -0,0 +1,133 @@
+package capture
+
+import (
+	"bytes"
+	"chip8/screenshot"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+)
+
+const framesPerSecond = 60
+
+type gifFrame struct {
+	pixels [][]uint8
+	// start is the number of the 60 Hz frame the image first appears in.
+	start int
+}
+
+// GIF records frames as an animated GIF that loops forever. A run of
+// identical frames becomes one image shown for their total time, and the
+// image/gif package needs every image before it can write the file, so the
+// frames are kept until Close. All images are the size of the largest
+// resolution recorded, at options.Scale.
+type GIF struct {
+	w       io.Writer
+	options screenshot.Options
+
+	frames []gifFrame
+	count  int
+	closed bool
+}
+
+func NewGIF(w io.Writer, options screenshot.Options) *GIF {
+	return &GIF{w: w, options: options}
+}
+
+func (g *GIF) Frame(pixels [][]uint8) error {
+	if n := len(g.frames); n == 0 || !equal(g.frames[n-1].pixels, pixels) {
+		g.frames = append(g.frames, gifFrame{pixels: copyPixels(pixels), start: g.count})
+	}
+
+	g.count++
+
+	return nil
+}
+
+func equal(a, b [][]uint8) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for y := range a {
+		if !bytes.Equal(a[y], b[y]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// centiseconds returns when a 60 Hz frame starts in the hundredths of a
+// second GIF delays are measured in.
+func centiseconds(frame int) int {
+	return frame * 100 / framesPerSecond
+}
+
+// Close writes the GIF. Nothing is written if no frames were recorded.
+func (g *GIF) Close() error {
+	if g.closed || len(g.frames) == 0 {
+		g.closed = true
+		return nil
+	}
+
+	g.closed = true
+
+	scale := g.options.Scale
+	if scale == 0 {
+		scale = 1
+	}
+
+	colours := screenshot.DefaultPalette
+	if g.options.Palette != nil {
+		colours = *g.options.Palette
+	}
+
+	palette := make(color.Palette, len(colours))
+	for i, c := range colours {
+		palette[i] = c
+	}
+
+	width, height := 0, 0
+	for _, f := range g.frames {
+		if h := len(f.pixels); h > height {
+			height = h
+			width = len(f.pixels[0])
+		}
+	}
+
+	anim := &gif.GIF{
+		Config: image.Config{
+			ColorModel: palette,
+			Width:      width * scale,
+			Height:     height * scale,
+		},
+	}
+
+	for i, f := range g.frames {
+		end := g.count
+		if i+1 < len(g.frames) {
+			end = g.frames[i+1].start
+		}
+
+		pixels := resize(f.pixels, width, height)
+
+		img := image.NewPaletted(image.Rect(0, 0, width*scale, height*scale), palette)
+		for y := 0; y < height*scale; y++ {
+			for x := 0; x < width*scale; x++ {
+				img.SetColorIndex(x, y, pixels[y/scale][x/scale]&3)
+			}
+		}
+
+		anim.Image = append(anim.Image, img)
+		anim.Delay = append(anim.Delay, centiseconds(end)-centiseconds(f.start))
+	}
+
+	if err := gif.EncodeAll(g.w, anim); err != nil {
+		return fmt.Errorf("failed to write GIF: %v", err)
+	}
+
+	return nil
+}
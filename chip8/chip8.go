@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"chip8/chip8/display"
 	"chip8/chip8/opcodes"
+	"crypto/sha1"
 	"encoding/binary"
 	"fmt"
 	"io"
 	"math/rand"
+	"time"
 )
 
 var fontSet = [80]uint8{
@@ -29,8 +31,71 @@ var fontSet = [80]uint8{
 	0xF0, 0x80, 0xF0, 0x80, 0x80, //F
 }
 
+const largeFontOffset = 0x50
+
+const (
+	// DefaultLoadAddress is where programs are loaded on the COSMAC VIP and
+	// most later machines.
+	DefaultLoadAddress = 0x200
+	// ETI660LoadAddress is where programs are loaded on the ETI-660.
+	ETI660LoadAddress = 0x600
+)
+
+var largeFontSet = [160]uint8{
+	0x3C, 0x7E, 0xE7, 0xC3, 0xC3, 0xC3, 0xC3, 0xE7, 0x7E, 0x3C, //0
+	0x18, 0x38, 0x58, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x3C, //1
+	0x3E, 0x7F, 0xC3, 0x06, 0x0C, 0x18, 0x30, 0x60, 0xFF, 0xFF, //2
+	0x3C, 0x7E, 0xC3, 0x03, 0x0E, 0x0E, 0x03, 0xC3, 0x7E, 0x3C, //3
+	0x06, 0x0E, 0x1E, 0x36, 0x66, 0xC6, 0xFF, 0xFF, 0x06, 0x06, //4
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFC, 0xFE, 0x03, 0xC3, 0x7E, 0x3C, //5
+	0x3E, 0x7C, 0xC0, 0xC0, 0xFC, 0xFE, 0xC3, 0xC3, 0x7E, 0x3C, //6
+	0xFF, 0xFF, 0x03, 0x06, 0x0C, 0x18, 0x30, 0x60, 0x60, 0x60, //7
+	0x3C, 0x7E, 0xC3, 0xC3, 0x7E, 0x7E, 0xC3, 0xC3, 0x7E, 0x3C, //8
+	0x3C, 0x7E, 0xC3, 0xC3, 0x7F, 0x3F, 0x03, 0x03, 0x3E, 0x7C, //9
+	0x18, 0x3C, 0x66, 0xC3, 0xC3, 0xFF, 0xFF, 0xC3, 0xC3, 0xC3, //A
+	0xFC, 0xFE, 0xC3, 0xC3, 0xFE, 0xFE, 0xC3, 0xC3, 0xFE, 0xFC, //B
+	0x3C, 0x7E, 0xC3, 0xC0, 0xC0, 0xC0, 0xC0, 0xC3, 0x7E, 0x3C, //C
+	0xFC, 0xFE, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xFE, 0xFC, //D
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFC, 0xFC, 0xC0, 0xC0, 0xFF, 0xFF, //E
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFC, 0xFC, 0xC0, 0xC0, 0xC0, 0xC0, //F
+}
+
+// Beeper is told every frame whether the sound timer is running, and should
+// play a tone for exactly as long as it is.
 type Beeper interface {
-	Beep()
+	SetSound(on bool)
+}
+
+// PatternBeeper is implemented by beepers that can play XO-CHIP audio
+// patterns. The pattern is 128 1-bit samples played back at a rate of
+// 4000*2^((pitch-64)/48) Hz.
+type PatternBeeper interface {
+	Beeper
+	SetPattern(pattern [16]uint8, pitch uint8)
+}
+
+const defaultPitch = 64
+
+var superChipInstructions = map[opcodes.Instruction]bool{
+	opcodes.Instruction00CN: true,
+	opcodes.Instruction00FB: true,
+	opcodes.Instruction00FC: true,
+	opcodes.Instruction00FD: true,
+	opcodes.Instruction00FE: true,
+	opcodes.Instruction00FF: true,
+	opcodes.InstructionFX30: true,
+	opcodes.InstructionFX75: true,
+	opcodes.InstructionFX85: true,
+}
+
+var xoChipInstructions = map[opcodes.Instruction]bool{
+	opcodes.Instruction00DN: true,
+	opcodes.Instruction5XY2: true,
+	opcodes.Instruction5XY3: true,
+	opcodes.InstructionF000: true,
+	opcodes.InstructionFN01: true,
+	opcodes.InstructionF002: true,
+	opcodes.InstructionFX3A: true,
 }
 
 type Keys interface {
@@ -46,76 +111,221 @@ type Chip8 struct {
 	stack [16]uint16
 	sp    uint16
 
-	memory [4096]uint8
+	memory []uint8
 
 	delayTimer uint8
 	soundTimer uint8
 
+	pattern [16]uint8
+	pitch   uint8
+
+	rpl [16]uint8
+
+	vblank bool
+	halted bool
+
+	// opcodePC and opcode are the instruction being executed.
+	opcodePC uint16
+	opcode   opcodes.Opcode
+
+	memoryHook func(MemoryAccess)
+	traceHook  func(Step)
+
+	romHash [sha1.Size]byte
+
+	random Random
+
+	quirks  Quirks
 	keys    Keys
 	beeper  Beeper
 	display *display.Display
 }
 
-func New(keys Keys, beeper Beeper, drawer display.Drawer) (*Chip8, error) {
+// New creates a machine. random supplies CXNN's numbers; if it is nil, they
+// come from math/rand seeded with the time.
+func New(keys Keys, beeper Beeper, drawer display.Drawer, quirks Quirks, random Random) (*Chip8, error) {
+	memorySize := 4096
+	if quirks.XOChip {
+		memorySize = 65536
+	}
+
+	if random == nil {
+		random = NewRandom(rand.NewSource(time.Now().UnixNano()))
+	}
+
 	c := &Chip8{
-		pc: 0x200,
+		pc: DefaultLoadAddress,
+
+		memory: make([]uint8, memorySize),
+		pitch:  defaultPitch,
+
+		random: random,
 
+		quirks:  quirks,
 		keys:    keys,
 		beeper:  beeper,
-		display: display.NewDisplay(drawer),
+		display: display.NewDisplay(drawer, quirks.WrapSprites),
 	}
 
 	copy(c.memory[:], fontSet[:])
+	copy(c.memory[largeFontOffset:], largeFontSet[:])
 
 	return c, nil
 }
 
+// LoadROM loads a program at DefaultLoadAddress.
 func (c *Chip8) LoadROM(r io.Reader) error {
+	return c.LoadROMAt(r, DefaultLoadAddress)
+}
+
+// LoadROMAt loads a program at addr and starts execution there.
+func (c *Chip8) LoadROMAt(r io.Reader, addr uint16) error {
+	if int(addr) >= len(c.memory) {
+		return fmt.Errorf("load address 0x%03X is outside memory", addr)
+	}
+
 	var b bytes.Buffer
 	_, err := b.ReadFrom(r)
 	if err != nil {
 		return fmt.Errorf("failed to load ROM: %v", err)
 	}
 
-	copy(c.memory[0x200:], b.Bytes())
+	if max := len(c.memory) - int(addr); b.Len() > max {
+		return &ROMTooLargeError{Size: b.Len(), Max: max}
+	}
+
+	copy(c.memory[addr:], b.Bytes())
+	c.pc = addr
+	c.romHash = sha1.Sum(b.Bytes())
 
 	return nil
 }
 
-func (c *Chip8) fetchAndDecode() opcodes.Opcode {
-	instruction := binary.BigEndian.Uint16(c.memory[c.pc : c.pc+2])
+func (c *Chip8) fetchAndDecode() (opcodes.Opcode, error) {
+	c.opcodePC = c.pc
+	c.opcode = 0
+
+	if err := c.checkMemory(int(c.pc), 2); err != nil {
+		return 0, err
+	}
+
+	c.opcode = opcodes.Opcode(binary.BigEndian.Uint16(c.memory[c.pc:]))
+
+	c.fetched(c.pc, 2)
 	c.pc += 2
 
-	return opcodes.Opcode(instruction)
+	return c.opcode, nil
+}
+
+// skip advances past the next instruction, which is four bytes long when it
+// is an XO-CHIP F000 NNNN long load.
+func (c *Chip8) skip() {
+	if c.quirks.XOChip && int(c.pc)+2 <= len(c.memory) && binary.BigEndian.Uint16(c.memory[c.pc:]) == 0xF000 {
+		c.pc += 2
+	}
+
+	c.pc += 2
 }
 
 func (c *Chip8) execute(opcode *opcodes.Opcode) error {
-	switch opcode.Instruction() {
+	instruction := opcode.Instruction()
+	superChip := c.quirks.SuperChip || c.quirks.XOChip
+
+	if xoChipInstructions[instruction] && !c.quirks.XOChip {
+		return &UnknownOpcodeError{Location: c.location()}
+	}
+
+	if superChipInstructions[instruction] && !superChip {
+		return &UnknownOpcodeError{Location: c.location()}
+	}
+
+	// Before SUPER-CHIP, DXY0 was a DXYN that draws no rows.
+	if instruction == opcodes.InstructionDXY0 && !superChip {
+		instruction = opcodes.InstructionDXYN
+	}
+
+	switch instruction {
+	case opcodes.Instruction00CN: // scroll down
+		err := c.display.ScrollDown(int(opcode.N()))
+		if err != nil {
+			return fmt.Errorf("execute 00CN failed: %v", err)
+		}
+	case opcodes.Instruction00DN: // scroll up
+		err := c.display.ScrollUp(int(opcode.N()))
+		if err != nil {
+			return fmt.Errorf("execute 00DN failed: %v", err)
+		}
 	case opcodes.Instruction00E0: // clear screen
 		err := c.display.Clear()
 		if err != nil {
 			return fmt.Errorf("execute 00E0 failed: %v", err)
 		}
 	case opcodes.Instruction00EE: // return
+		if c.sp == 0 {
+			return &StackUnderflowError{Location: c.location()}
+		}
+
 		c.sp--
 		c.pc = c.stack[c.sp]
+	case opcodes.Instruction00FB: // scroll right
+		err := c.display.ScrollRight(4)
+		if err != nil {
+			return fmt.Errorf("execute 00FB failed: %v", err)
+		}
+	case opcodes.Instruction00FC: // scroll left
+		err := c.display.ScrollLeft(4)
+		if err != nil {
+			return fmt.Errorf("execute 00FC failed: %v", err)
+		}
+	case opcodes.Instruction00FD: // exit
+		c.halted = true
+	case opcodes.Instruction00FE: // low resolution
+		err := c.display.SetHighRes(false)
+		if err != nil {
+			return fmt.Errorf("execute 00FE failed: %v", err)
+		}
+	case opcodes.Instruction00FF: // high resolution
+		err := c.display.SetHighRes(true)
+		if err != nil {
+			return fmt.Errorf("execute 00FF failed: %v", err)
+		}
 	case opcodes.Instruction1NNN: // jump
 		c.pc = opcode.NNN()
 	case opcodes.Instruction2NNN: // call
+		if int(c.sp) >= len(c.stack) {
+			return &StackOverflowError{Location: c.location()}
+		}
+
 		c.stack[c.sp] = c.pc
 		c.sp++
 		c.pc = opcode.NNN()
 	case opcodes.Instruction3XNN: // skip
 		if c.v[opcode.X()] == opcode.NN() {
-			c.pc += 2
+			c.skip()
 		}
 	case opcodes.Instruction4XNN: // skip
 		if c.v[opcode.X()] != opcode.NN() {
-			c.pc += 2
+			c.skip()
 		}
 	case opcodes.Instruction5XY0: // skip?
 		if c.v[opcode.X()] == c.v[opcode.Y()] {
-			c.pc += 2
+			c.skip()
+		}
+	case opcodes.Instruction5XY2: // save range
+		if err := c.checkMemory(int(c.i), len(registerRange(opcode.X(), opcode.Y()))); err != nil {
+			return err
+		}
+
+		for n, x := range registerRange(opcode.X(), opcode.Y()) {
+			c.write(c.i+uint16(n), c.v[x])
+		}
+	case opcodes.Instruction5XY3: // load range
+		if err := c.checkMemory(int(c.i), len(registerRange(opcode.X(), opcode.Y()))); err != nil {
+			return err
+		}
+
+		for n, x := range registerRange(opcode.X(), opcode.Y()) {
+			c.v[x] = c.read(c.i + uint16(n))
 		}
 	case opcodes.Instruction6XNN: // set
 		c.v[opcode.X()] = opcode.NN()
@@ -125,10 +335,22 @@ func (c *Chip8) execute(opcode *opcodes.Opcode) error {
 		c.v[opcode.X()] = c.v[opcode.Y()]
 	case opcodes.Instruction8XY1: // or
 		c.v[opcode.X()] |= c.v[opcode.Y()]
+
+		if c.quirks.LogicResetsVF {
+			c.v[0xF] = 0
+		}
 	case opcodes.Instruction8XY2: // and
 		c.v[opcode.X()] &= c.v[opcode.Y()]
+
+		if c.quirks.LogicResetsVF {
+			c.v[0xF] = 0
+		}
 	case opcodes.Instruction8XY3: // xor
 		c.v[opcode.X()] ^= c.v[opcode.Y()]
+
+		if c.quirks.LogicResetsVF {
+			c.v[0xF] = 0
+		}
 	case opcodes.Instruction8XY4: // add
 		result := uint16(c.v[opcode.X()]) + uint16(c.v[opcode.Y()])
 
@@ -150,7 +372,10 @@ func (c *Chip8) execute(opcode *opcodes.Opcode) error {
 
 		c.v[opcode.X()] = uint8(result & 0xFF)
 	case opcodes.Instruction8XY6: // shift
-		c.v[opcode.X()] = c.v[opcode.Y()]
+		if c.quirks.ShiftUsesVY {
+			c.v[opcode.X()] = c.v[opcode.Y()]
+		}
+
 		c.v[0xF] = c.v[opcode.X()] & 0x1
 		c.v[opcode.X()] = c.v[opcode.X()] >> 1
 	case opcodes.Instruction8XY7: // sub
@@ -164,38 +389,97 @@ func (c *Chip8) execute(opcode *opcodes.Opcode) error {
 
 		c.v[opcode.X()] = uint8(result & 0xFF)
 	case opcodes.Instruction8XYE: // shift
-		c.v[opcode.X()] = c.v[opcode.Y()]
+		if c.quirks.ShiftUsesVY {
+			c.v[opcode.X()] = c.v[opcode.Y()]
+		}
+
 		c.v[0xF] = c.v[opcode.X()] >> 7
 		c.v[opcode.X()] = c.v[opcode.X()] << 1
 	case opcodes.Instruction9XY0: // skip
 		if c.v[opcode.X()] != c.v[opcode.Y()] {
-			c.pc += 2
+			c.skip()
 		}
 	case opcodes.InstructionANNN: // set index
 		c.i = opcode.NNN()
 	case opcodes.InstructionBNNN: // jump with offset
-		c.pc = uint16(c.v[0]) + opcode.NNN()
+		if c.quirks.JumpUsesVX {
+			c.pc = uint16(c.v[opcode.X()]) + opcode.NNN()
+		} else {
+			c.pc = uint16(c.v[0]) + opcode.NNN()
+		}
 	case opcodes.InstructionCXNN: // random
-		c.v[opcode.X()] = uint8(rand.Intn(256)) & opcode.NN()
+		c.v[opcode.X()] = c.random.Byte() & opcode.NN()
 	case opcodes.InstructionDXYN: // display
-		x := c.v[opcode.X()] % 64
-		y := c.v[opcode.Y()] % 32
-		sprite := c.memory[c.i : c.i+uint16(opcode.N())]
+		if c.quirks.DisplayWait && !c.vblank {
+			c.pc -= 2
+			break
+		}
+
+		size := int(opcode.N()) * c.display.SelectedPlaneCount()
+		if err := c.checkMemory(int(c.i), size); err != nil {
+			return err
+		}
+
+		c.vblank = false
+
+		x := c.v[opcode.X()] % uint8(c.display.Width())
+		y := c.v[opcode.Y()] % uint8(c.display.Height())
+		sprite := c.readSlice(c.i, size)
 
 		vf, err := c.display.DrawSprite(x, y, sprite)
 		if err != nil {
 			return fmt.Errorf("execute DXYN failed: %v", err)
 		}
 
+		c.v[0xF] = vf
+	case opcodes.InstructionDXY0: // display large sprite
+		if c.quirks.DisplayWait && !c.vblank {
+			c.pc -= 2
+			break
+		}
+
+		size := 32 * c.display.SelectedPlaneCount()
+		if err := c.checkMemory(int(c.i), size); err != nil {
+			return err
+		}
+
+		c.vblank = false
+
+		x := c.v[opcode.X()] % uint8(c.display.Width())
+		y := c.v[opcode.Y()] % uint8(c.display.Height())
+		sprite := c.readSlice(c.i, size)
+
+		vf, err := c.display.DrawLargeSprite(x, y, sprite)
+		if err != nil {
+			return fmt.Errorf("execute DXY0 failed: %v", err)
+		}
+
 		c.v[0xF] = vf
 	case opcodes.InstructionEX9E: // skip if key
 		if c.keys.IsKeyDown(c.v[opcode.X()]) {
-			c.pc += 2
+			c.skip()
 		}
 	case opcodes.InstructionEXA1: // skip if not key
 		if !c.keys.IsKeyDown(c.v[opcode.X()]) {
-			c.pc += 2
+			c.skip()
+		}
+	case opcodes.InstructionF000: // long index
+		if err := c.checkMemory(int(c.pc), 2); err != nil {
+			return err
 		}
+
+		c.i = binary.BigEndian.Uint16(c.memory[c.pc:])
+		c.fetched(c.pc, 2)
+		c.pc += 2
+	case opcodes.InstructionFN01: // select planes
+		c.display.SelectPlanes(opcode.X())
+	case opcodes.InstructionF002: // audio pattern
+		if err := c.checkMemory(int(c.i), len(c.pattern)); err != nil {
+			return err
+		}
+
+		copy(c.pattern[:], c.readSlice(c.i, len(c.pattern)))
+		c.setPattern()
 	// timers
 	case opcodes.InstructionFX07:
 		c.v[opcode.X()] = c.delayTimer
@@ -220,44 +504,205 @@ func (c *Chip8) execute(opcode *opcodes.Opcode) error {
 			c.pc -= 2
 		}
 	case opcodes.InstructionFX29: // font char
-		c.i = uint16(c.v[opcode.X()]) * 5
+		c.i = uint16(c.v[opcode.X()]&0xF) * 5
+	case opcodes.InstructionFX30: // large font char
+		c.i = largeFontOffset + uint16(c.v[opcode.X()]&0xF)*10
 	case opcodes.InstructionFX33: // decimal conversion
-		c.memory[c.i] = c.v[int(opcode.X())] / 100
-		c.memory[c.i+1] = (c.v[int(opcode.X())] / 10) % 10
-		c.memory[c.i+2] = (c.v[int(opcode.X())] % 100) / 10
+		if err := c.checkMemory(int(c.i), 3); err != nil {
+			return err
+		}
+
+		c.write(c.i, c.v[int(opcode.X())]/100)
+		c.write(c.i+1, (c.v[int(opcode.X())]/10)%10)
+		c.write(c.i+2, c.v[int(opcode.X())]%10)
+	case opcodes.InstructionFX3A: // pitch
+		c.pitch = c.v[opcode.X()]
+		c.setPattern()
 	case opcodes.InstructionFX55: // store
+		if err := c.checkMemory(int(c.i), int(opcode.X())+1); err != nil {
+			return err
+		}
+
 		for x := uint8(0); x < opcode.X()+1; x++ {
-			c.memory[c.i+uint16(x)] = c.v[x]
+			c.write(c.i+uint16(x), c.v[x])
 		}
+
+		c.incrementIndex(opcode.X())
 	case opcodes.InstructionFX65: // load
+		if err := c.checkMemory(int(c.i), int(opcode.X())+1); err != nil {
+			return err
+		}
+
 		for x := uint8(0); x < opcode.X()+1; x++ {
-			c.v[x] = c.memory[c.i+uint16(x)]
+			c.v[x] = c.read(c.i + uint16(x))
 		}
+
+		c.incrementIndex(opcode.X())
+	case opcodes.InstructionFX75: // store flags
+		copy(c.rpl[:opcode.X()+1], c.v[:opcode.X()+1])
+	case opcodes.InstructionFX85: // load flags
+		copy(c.v[:opcode.X()+1], c.rpl[:opcode.X()+1])
 	default:
-		return fmt.Errorf("unknown opcode @ %v: %v", c.pc, opcode)
+		return &UnknownOpcodeError{Location: c.location()}
 	}
 
 	return nil
 }
 
+// registerRange returns the registers from x to y inclusive, in descending
+// order when x > y.
+func registerRange(x, y uint8) []uint8 {
+	step := 1
+	if x > y {
+		step = -1
+	}
+
+	registers := []uint8{x}
+	for r := int(x); r != int(y); {
+		r += step
+		registers = append(registers, uint8(r))
+	}
+
+	return registers
+}
+
+func (c *Chip8) setPattern() {
+	if b, ok := c.beeper.(PatternBeeper); ok {
+		b.SetPattern(c.pattern, c.pitch)
+	}
+}
+
+func (c *Chip8) incrementIndex(x uint8) {
+	switch c.quirks.IndexIncrement {
+	case IndexIncrementX:
+		c.i += uint16(x)
+	case IndexIncrementXPlusOne:
+		c.i += uint16(x) + 1
+	}
+}
+
+// ROMHash returns the SHA-1 hash of the most recently loaded ROM.
+func (c *Chip8) ROMHash() [sha1.Size]byte {
+	return c.romHash
+}
+
+// Seed restarts the random number generator used by CXNN from seed, so
+// that a run can be repeated exactly.
+func (c *Chip8) Seed(seed int64) {
+	c.random.Seed(seed)
+}
+
+// Halted reports whether the program has exited with 00FD.
+func (c *Chip8) Halted() bool {
+	return c.halted
+}
+
+// RPLFlags returns the persistent flag registers written by FX75.
+func (c *Chip8) RPLFlags() [16]uint8 {
+	return c.rpl
+}
+
+// SetRPLFlags restores flag registers saved by a previous run.
+func (c *Chip8) SetRPLFlags(flags [16]uint8) {
+	c.rpl = flags
+}
+
+// Registers is a snapshot of the machine's registers.
+type Registers struct {
+	V     [16]uint8
+	I     uint16
+	PC    uint16
+	SP    uint16
+	Stack [16]uint16
+
+	DelayTimer uint8
+	SoundTimer uint8
+}
+
+// Registers returns a snapshot of the registers, stack and timers.
+func (c *Chip8) Registers() Registers {
+	return Registers{
+		V:     c.v,
+		I:     c.i,
+		PC:    c.pc,
+		SP:    c.sp,
+		Stack: c.stack,
+
+		DelayTimer: c.delayTimer,
+		SoundTimer: c.soundTimer,
+	}
+}
+
+// ReadMemory copies memory starting at addr into b and returns the number of
+// bytes copied, which is less than len(b) at the end of memory.
+func (c *Chip8) ReadMemory(addr uint16, b []byte) int {
+	if int(addr) >= len(c.memory) {
+		return 0
+	}
+
+	return copy(b, c.memory[addr:])
+}
+
+// Cycle fetches and executes a single instruction. Timers are not affected;
+// call TickTimers at 60 Hz, or use RunFrame. It returns a Fault if the
+// program does something the machine cannot execute.
 func (c *Chip8) Cycle() error {
-	opcode := c.fetchAndDecode()
+	if c.halted {
+		return nil
+	}
+
+	var before Registers
+	if c.traceHook != nil {
+		before = c.Registers()
+	}
+
+	opcode, err := c.fetchAndDecode()
+	if err != nil {
+		return err
+	}
 
 	if err := c.execute(&opcode); err != nil {
+		if _, ok := err.(Fault); ok {
+			c.pc = c.opcodePC
+			return err
+		}
+
 		return fmt.Errorf("failed to execute opcode: %v", err)
 	}
 
+	if c.traceHook != nil {
+		c.trace(before, opcode)
+	}
+
+	return nil
+}
+
+// TickTimers decrements the delay and sound timers and signals the vertical
+// blank. It should be called at 60 Hz.
+func (c *Chip8) TickTimers() {
+	c.vblank = true
+
 	if c.delayTimer > 0 {
 		c.delayTimer--
 	}
 
+	c.beeper.SetSound(c.soundTimer > 0)
+
 	if c.soundTimer > 0 {
 		c.soundTimer--
+	}
+}
 
-		if c.soundTimer == 0 {
-			c.beeper.Beep()
+// RunFrame executes cyclesPerFrame instructions followed by a timer tick,
+// emulating one 60 Hz frame. It stops early if the program exits.
+func (c *Chip8) RunFrame(cyclesPerFrame int) error {
+	for i := 0; i < cyclesPerFrame && !c.halted; i++ {
+		if err := c.Cycle(); err != nil {
+			return err
 		}
 	}
 
+	c.TickTimers()
+
 	return nil
 }
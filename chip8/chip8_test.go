@@ -0,0 +1,559 @@
+package chip8
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockKeys struct {
+	mock.Mock
+}
+
+func (m *MockKeys) IsKeyDown(i uint8) bool {
+	args := m.Called(i)
+	return args.Bool(0)
+}
+
+func (m *MockKeys) WasKeyReleased(i uint8) bool {
+	args := m.Called(i)
+	return args.Bool(0)
+}
+
+type MockBeeper struct {
+	mock.Mock
+}
+
+func (m *MockBeeper) SetSound(on bool) {
+	m.Called(on)
+}
+
+type MockDrawer struct {
+	mock.Mock
+}
+
+func (m *MockDrawer) Draw(pixels [][]uint8) error {
+	args := m.Called(pixels)
+	return args.Error(0)
+}
+
+func newTestChip8(quirks Quirks, program ...uint16) (*Chip8, error) {
+	drawer := new(MockDrawer)
+	drawer.On("Draw", mock.Anything).Return(nil)
+
+	beeper := new(MockBeeper)
+	beeper.On("SetSound", mock.Anything).Return()
+
+	c, err := New(new(MockKeys), beeper, drawer, quirks, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var rom bytes.Buffer
+	for _, instruction := range program {
+		_ = binary.Write(&rom, binary.BigEndian, instruction)
+	}
+
+	return c, c.LoadROM(&rom)
+}
+
+type QuirksSuite struct {
+	suite.Suite
+}
+
+func (suite *QuirksSuite) run(c *Chip8, cycles int) {
+	for i := 0; i < cycles; i++ {
+		suite.Require().Nil(c.Cycle())
+	}
+}
+
+func (suite *QuirksSuite) TestShiftUsesVY() {
+	program := []uint16{0x6001, 0x6106, 0x8016}
+
+	c, err := newTestChip8(Quirks{ShiftUsesVY: true}, program...)
+	suite.Require().Nil(err)
+	suite.run(c, 3)
+	suite.Assert().Equal(uint8(0x03), c.v[0])
+
+	c, err = newTestChip8(Quirks{}, program...)
+	suite.Require().Nil(err)
+	suite.run(c, 3)
+	suite.Assert().Equal(uint8(0x00), c.v[0])
+	suite.Assert().Equal(uint8(0x01), c.v[0xF])
+}
+
+func (suite *QuirksSuite) TestJumpUsesVX() {
+	program := []uint16{0x6004, 0x6302, 0xB300}
+
+	c, err := newTestChip8(Quirks{JumpUsesVX: true}, program...)
+	suite.Require().Nil(err)
+	suite.run(c, 3)
+	suite.Assert().Equal(uint16(0x302), c.pc)
+
+	c, err = newTestChip8(Quirks{}, program...)
+	suite.Require().Nil(err)
+	suite.run(c, 3)
+	suite.Assert().Equal(uint16(0x304), c.pc)
+}
+
+func (suite *QuirksSuite) TestIndexIncrement() {
+	program := []uint16{0xA300, 0xF255}
+
+	for increment, expected := range map[IndexIncrement]uint16{
+		IndexUnchanged:         0x300,
+		IndexIncrementX:        0x302,
+		IndexIncrementXPlusOne: 0x303,
+	} {
+		c, err := newTestChip8(Quirks{IndexIncrement: increment}, program...)
+		suite.Require().Nil(err)
+		suite.run(c, 2)
+		suite.Assert().Equal(expected, c.i)
+	}
+}
+
+func (suite *QuirksSuite) TestLogicResetsVF() {
+	program := []uint16{0x6F05, 0x8011}
+
+	c, err := newTestChip8(Quirks{LogicResetsVF: true}, program...)
+	suite.Require().Nil(err)
+	suite.run(c, 2)
+	suite.Assert().Equal(uint8(0), c.v[0xF])
+
+	c, err = newTestChip8(Quirks{}, program...)
+	suite.Require().Nil(err)
+	suite.run(c, 2)
+	suite.Assert().Equal(uint8(5), c.v[0xF])
+}
+
+func (suite *QuirksSuite) TestLegacy() {
+	program := []uint16{
+		0x6106, // v1 := 6
+		0x8016, // v0 := v1 >> 1
+		0x6F05, // vf := 5
+		0x8011, // v0 |= v1
+		0xA300, // i := 0x300
+		0xF255, // save v2
+		0xD005, // sprite v0 v0 5
+		0xD005, // sprite v0 v0 5
+		0x6000, // v0 := 0
+		0xB220, // jump0 0x220
+	}
+
+	c, err := newTestChip8(QuirksLegacy, program...)
+	suite.Require().Nil(err)
+
+	suite.run(c, 4)
+	suite.Assert().Equal(uint8(7), c.v[0])
+	suite.Assert().Equal(uint8(5), c.v[0xF])
+
+	suite.run(c, 2)
+	suite.Assert().Equal(uint16(0x300), c.i)
+
+	// Both draws run in the same frame, and the jump adds V0.
+	suite.run(c, 4)
+	suite.Assert().Equal(uint16(0x220), c.pc)
+
+	q, err := QuirksByName("legacy")
+	suite.Require().Nil(err)
+	suite.Assert().Equal(QuirksLegacy, q)
+}
+
+func (suite *QuirksSuite) TestBinary() {
+	for _, q := range []Quirks{QuirksLegacy, QuirksVIP, QuirksCHIP48, QuirksSuperChip, QuirksXOChip} {
+		suite.Assert().Equal(q, q.Binary().Quirks())
+	}
+}
+
+func TestQuirks(t *testing.T) {
+	suite.Run(t, new(QuirksSuite))
+}
+
+type SuperChipSuite struct {
+	suite.Suite
+}
+
+func (suite *SuperChipSuite) TestExit() {
+	c, err := newTestChip8(QuirksSuperChip, 0x00FD, 0x6001)
+	suite.Require().Nil(err)
+
+	suite.Require().Nil(c.Cycle())
+	suite.Require().Nil(c.Cycle())
+	suite.Assert().True(c.Halted())
+	suite.Assert().Equal(uint8(0), c.v[0])
+}
+
+func (suite *SuperChipSuite) TestHighRes() {
+	c, err := newTestChip8(QuirksSuperChip, 0x00FF)
+	suite.Require().Nil(err)
+
+	suite.Require().Nil(c.Cycle())
+	suite.Assert().True(c.display.HighRes())
+}
+
+func (suite *SuperChipSuite) TestRPLFlags() {
+	c, err := newTestChip8(QuirksSuperChip, 0x6007, 0x6109, 0xF175, 0x6000, 0x6100, 0xF185)
+	suite.Require().Nil(err)
+
+	for i := 0; i < 3; i++ {
+		suite.Require().Nil(c.Cycle())
+	}
+
+	flags := c.RPLFlags()
+	suite.Assert().Equal(uint8(7), flags[0])
+	suite.Assert().Equal(uint8(9), flags[1])
+
+	for i := 0; i < 3; i++ {
+		suite.Require().Nil(c.Cycle())
+	}
+
+	suite.Assert().Equal(uint8(7), c.v[0])
+	suite.Assert().Equal(uint8(9), c.v[1])
+}
+
+func (suite *SuperChipSuite) TestLargeFont() {
+	c, err := newTestChip8(QuirksSuperChip, 0x6009, 0xF030)
+	suite.Require().Nil(err)
+
+	suite.Require().Nil(c.Cycle())
+	suite.Require().Nil(c.Cycle())
+	suite.Assert().Equal(uint16(largeFontOffset+90), c.i)
+}
+
+func (suite *SuperChipSuite) TestRequiresSuperChip() {
+	for _, op := range []uint16{0x00C1, 0x00FB, 0x00FC, 0x00FD, 0x00FE, 0x00FF, 0xF030, 0xF075, 0xF085} {
+		c, err := newTestChip8(QuirksCHIP48, op)
+		suite.Require().Nil(err)
+
+		var unknown *UnknownOpcodeError
+		suite.Assert().ErrorAs(c.Cycle(), &unknown, "%04X", op)
+	}
+
+	c, err := newTestChip8(QuirksXOChip, 0x00FD)
+	suite.Require().Nil(err)
+	suite.Require().Nil(c.Cycle())
+	suite.Assert().True(c.Halted())
+}
+
+func (suite *SuperChipSuite) TestEmptySpriteWithoutSuperChip() {
+	for _, q := range []Quirks{QuirksLegacy, QuirksVIP, QuirksCHIP48} {
+		c, err := newTestChip8(q, 0x6F05, 0xD000, 0x6001)
+		suite.Require().Nil(err)
+
+		c.TickTimers()
+
+		suite.Require().Nil(c.RunFrame(3))
+		suite.Assert().Equal(uint8(0), c.v[0xF])
+		suite.Assert().Equal(uint8(1), c.v[0])
+		suite.Assert().Equal(uint16(0x206), c.pc)
+	}
+}
+
+func (suite *SuperChipSuite) TestLargeSpriteDisplayWait() {
+	q := QuirksSuperChip
+	q.DisplayWait = true
+
+	c, err := newTestChip8(q, 0xD000, 0xD000, 0x1204)
+	suite.Require().Nil(err)
+
+	c.TickTimers()
+
+	suite.Require().Nil(c.RunFrame(10))
+	suite.Assert().Equal(uint16(0x202), c.pc)
+
+	suite.Require().Nil(c.RunFrame(10))
+	suite.Assert().Equal(uint16(0x204), c.pc)
+}
+
+func TestSuperChip(t *testing.T) {
+	suite.Run(t, new(SuperChipSuite))
+}
+
+type XOChipSuite struct {
+	suite.Suite
+}
+
+func (suite *XOChipSuite) TestLongIndex() {
+	c, err := newTestChip8(QuirksXOChip, 0xF000, 0xBEEF, 0x6001)
+	suite.Require().Nil(err)
+
+	suite.Require().Nil(c.Cycle())
+	suite.Assert().Equal(uint16(0xBEEF), c.i)
+	suite.Assert().Equal(uint16(0x204), c.pc)
+}
+
+func (suite *XOChipSuite) TestSkipLongIndex() {
+	c, err := newTestChip8(QuirksXOChip, 0x3000, 0xF000, 0xBEEF, 0x6001)
+	suite.Require().Nil(err)
+
+	suite.Require().Nil(c.Cycle())
+	suite.Assert().Equal(uint16(0x206), c.pc)
+}
+
+func (suite *XOChipSuite) TestRegisterRange() {
+	c, err := newTestChip8(QuirksXOChip, 0x6101, 0x6202, 0x6303, 0xA400, 0x5312, 0x6100, 0x5133)
+	suite.Require().Nil(err)
+
+	for i := 0; i < 7; i++ {
+		suite.Require().Nil(c.Cycle())
+	}
+
+	suite.Assert().Equal([]uint8{3, 2, 1}, c.memory[0x400:0x403])
+	suite.Assert().Equal(uint8(3), c.v[1])
+	suite.Assert().Equal(uint8(2), c.v[2])
+	suite.Assert().Equal(uint8(1), c.v[3])
+}
+
+func (suite *XOChipSuite) TestRequiresXOChip() {
+	c, err := newTestChip8(QuirksSuperChip, 0xF000, 0xBEEF)
+	suite.Require().Nil(err)
+
+	suite.Assert().NotNil(c.Cycle())
+}
+
+func (suite *XOChipSuite) TestMemorySize() {
+	c, err := newTestChip8(QuirksXOChip)
+	suite.Require().Nil(err)
+
+	suite.Assert().Len(c.memory, 65536)
+}
+
+func TestXOChip(t *testing.T) {
+	suite.Run(t, new(XOChipSuite))
+}
+
+type TimersSuite struct {
+	suite.Suite
+}
+
+func (suite *TimersSuite) TestCycleDoesNotTickTimers() {
+	c, err := newTestChip8(QuirksVIP, 0x603C, 0xF015, 0xF018, 0x1206)
+	suite.Require().Nil(err)
+
+	for i := 0; i < 100; i++ {
+		suite.Require().Nil(c.Cycle())
+	}
+
+	suite.Assert().Equal(uint8(60), c.delayTimer)
+	suite.Assert().Equal(uint8(60), c.soundTimer)
+}
+
+func (suite *TimersSuite) TestTimerRateIsIndependentOfIPF() {
+	for _, ipf := range []int{3, 8, 15, 100, 1000} {
+		c, err := newTestChip8(QuirksVIP, 0x603C, 0xF015, 0xF018, 0x1206)
+		suite.Require().Nil(err)
+
+		for frame := 0; frame < 10; frame++ {
+			suite.Require().Nil(c.RunFrame(ipf))
+		}
+
+		suite.Assert().Equal(uint8(50), c.delayTimer)
+		suite.Assert().Equal(uint8(50), c.soundTimer)
+	}
+}
+
+func (suite *TimersSuite) TestSound() {
+	c, err := newTestChip8(QuirksVIP, 0x6003, 0xF018, 0x1204)
+	suite.Require().Nil(err)
+
+	for frame := 0; frame < 6; frame++ {
+		suite.Require().Nil(c.RunFrame(8))
+	}
+
+	var sound []bool
+	for _, call := range c.beeper.(*MockBeeper).Calls {
+		sound = append(sound, call.Arguments.Bool(0))
+	}
+
+	suite.Assert().Equal([]bool{true, true, true, false, false, false}, sound)
+}
+
+func (suite *TimersSuite) TestDisplayWait() {
+	c, err := newTestChip8(QuirksVIP, 0xD001, 0xD001, 0x1204)
+	suite.Require().Nil(err)
+
+	c.TickTimers()
+
+	suite.Require().Nil(c.RunFrame(10))
+	suite.Assert().Equal(uint16(0x202), c.pc)
+
+	suite.Require().Nil(c.RunFrame(10))
+	suite.Assert().Equal(uint16(0x204), c.pc)
+}
+
+func TestTimers(t *testing.T) {
+	suite.Run(t, new(TimersSuite))
+}
+
+type MemoryHookSuite struct {
+	suite.Suite
+}
+
+func (suite *MemoryHookSuite) record(c *Chip8, access Access) *[]MemoryAccess {
+	var accesses []MemoryAccess
+
+	c.SetMemoryHook(func(a MemoryAccess) {
+		if a.Access == access {
+			accesses = append(accesses, a)
+		}
+	})
+
+	return &accesses
+}
+
+func (suite *MemoryHookSuite) TestExecute() {
+	c, err := newTestChip8(QuirksVIP, 0x6005)
+	suite.Require().Nil(err)
+
+	accesses := suite.record(c, AccessExecute)
+	suite.Require().Nil(c.Cycle())
+
+	suite.Assert().Equal([]MemoryAccess{
+		{Access: AccessExecute, PC: 0x200, Opcode: 0x6005, Address: 0x200, Old: 0x60, New: 0x60},
+		{Access: AccessExecute, PC: 0x200, Opcode: 0x6005, Address: 0x201, Old: 0x05, New: 0x05},
+	}, *accesses)
+}
+
+func (suite *MemoryHookSuite) TestWrite() {
+	c, err := newTestChip8(QuirksVIP, 0x607B, 0xA300, 0xF033)
+	suite.Require().Nil(err)
+
+	accesses := suite.record(c, AccessWrite)
+	for i := 0; i < 3; i++ {
+		suite.Require().Nil(c.Cycle())
+	}
+
+	suite.Require().Len(*accesses, 3)
+	suite.Assert().Equal(MemoryAccess{Access: AccessWrite, PC: 0x204, Opcode: 0xF033, Address: 0x300, Old: 0, New: 1}, (*accesses)[0])
+	suite.Assert().Equal(uint16(0x302), (*accesses)[2].Address)
+
+	// 0x7B is 123.
+	suite.Assert().Equal([]uint8{1, 2, 3}, c.memory[0x300:0x303])
+}
+
+func (suite *MemoryHookSuite) TestRead() {
+	c, err := newTestChip8(Quirks{}, 0xA000, 0xD005, 0xF165)
+	suite.Require().Nil(err)
+
+	accesses := suite.record(c, AccessRead)
+	for i := 0; i < 3; i++ {
+		suite.Require().Nil(c.Cycle())
+	}
+
+	suite.Require().Len(*accesses, 7)
+	suite.Assert().Equal(uint16(0x202), (*accesses)[0].PC)
+	suite.Assert().Equal(uint16(0x004), (*accesses)[4].Address)
+	suite.Assert().Equal(MemoryAccess{Access: AccessRead, PC: 0x204, Opcode: 0xF165, Address: 0x001, Old: 0x90, New: 0x90}, (*accesses)[6])
+}
+
+func TestMemoryHook(t *testing.T) {
+	suite.Run(t, new(MemoryHookSuite))
+}
+
+type FaultsSuite struct {
+	suite.Suite
+}
+
+func (suite *FaultsSuite) TestStackUnderflow() {
+	c, err := newTestChip8(QuirksVIP, 0x00EE)
+	suite.Require().Nil(err)
+
+	err = c.Cycle()
+
+	var fault *StackUnderflowError
+	suite.Require().True(errors.As(err, &fault))
+	suite.Assert().Equal(Location{PC: 0x200, Opcode: 0x00EE}, fault.FaultLocation())
+	suite.Assert().Equal(uint16(0x200), c.pc)
+}
+
+func (suite *FaultsSuite) TestStackOverflow() {
+	c, err := newTestChip8(QuirksVIP, 0x2200)
+	suite.Require().Nil(err)
+
+	for i := 0; i < len(c.stack); i++ {
+		suite.Require().Nil(c.Cycle())
+	}
+
+	var fault *StackOverflowError
+	suite.Assert().True(errors.As(c.Cycle(), &fault))
+	suite.Assert().Equal(uint16(len(c.stack)), c.sp)
+}
+
+func (suite *FaultsSuite) TestFetchOutOfBounds() {
+	c, err := newTestChip8(QuirksVIP, 0x1FFF)
+	suite.Require().Nil(err)
+	suite.Require().Nil(c.Cycle())
+
+	var fault *MemoryOutOfBoundsError
+	suite.Require().True(errors.As(c.Cycle(), &fault))
+	suite.Assert().Equal(0xFFF, fault.Address)
+	suite.Assert().Equal(uint16(0xFFF), fault.PC)
+}
+
+func (suite *FaultsSuite) TestStoreOutOfBounds() {
+	c, err := newTestChip8(QuirksVIP, 0x6001, 0xAFFF, 0xF155)
+	suite.Require().Nil(err)
+	suite.Require().Nil(c.Cycle())
+	suite.Require().Nil(c.Cycle())
+
+	var fault *MemoryOutOfBoundsError
+	suite.Require().True(errors.As(c.Cycle(), &fault))
+	suite.Assert().Equal(MemoryOutOfBoundsError{Location: Location{PC: 0x204, Opcode: 0xF155}, Address: 0xFFF, Size: 2}, *fault)
+	suite.Assert().Equal(uint8(0), c.memory[0xFFF])
+	suite.Assert().Equal(uint16(0x204), c.pc)
+}
+
+func (suite *FaultsSuite) TestUnknownOpcode() {
+	c, err := newTestChip8(QuirksVIP, 0x5001)
+	suite.Require().Nil(err)
+
+	err = c.Cycle()
+
+	var fault Fault
+	suite.Require().True(errors.As(err, &fault))
+	suite.Assert().IsType(&UnknownOpcodeError{}, fault)
+	suite.Assert().Equal("unknown opcode at 0x200: 5001", err.Error())
+}
+
+func (suite *FaultsSuite) TestROMTooLarge() {
+	c, err := New(new(MockKeys), new(MockBeeper), new(MockDrawer), QuirksVIP, nil)
+	suite.Require().Nil(err)
+
+	err = c.LoadROM(bytes.NewReader(make([]byte, 4096-0x200+1)))
+	suite.Assert().Equal(&ROMTooLargeError{Size: 3585, Max: 3584}, err)
+	suite.Assert().Nil(c.LoadROM(bytes.NewReader(make([]byte, 4096-0x200))))
+}
+
+func TestFaults(t *testing.T) {
+	suite.Run(t, new(FaultsSuite))
+}
+
+type LoadROMSuite struct {
+	suite.Suite
+}
+
+func (suite *LoadROMSuite) TestLoadAddress() {
+	c, err := New(new(MockKeys), new(MockBeeper), new(MockDrawer), QuirksVIP, nil)
+	suite.Require().Nil(err)
+
+	suite.Require().Nil(c.LoadROMAt(bytes.NewReader([]byte{0x12, 0x34}), ETI660LoadAddress))
+	suite.Assert().Equal(uint16(0x600), c.pc)
+	suite.Assert().Equal([]uint8{0x12, 0x34}, c.memory[0x600:0x602])
+}
+
+func (suite *LoadROMSuite) TestTooLargeForLoadAddress() {
+	c, err := New(new(MockKeys), new(MockBeeper), new(MockDrawer), QuirksVIP, nil)
+	suite.Require().Nil(err)
+
+	err = c.LoadROMAt(bytes.NewReader(make([]byte, 3000)), ETI660LoadAddress)
+	suite.Assert().Equal(&ROMTooLargeError{Size: 3000, Max: 2560}, err)
+
+	suite.Assert().Error(c.LoadROMAt(bytes.NewReader(nil), 0x1000))
+}
+
+func TestLoadROM(t *testing.T) {
+	suite.Run(t, new(LoadROMSuite))
+}
@@ -1,62 +1,298 @@
 package display
 
+import (
+	"errors"
+	"fmt"
+)
+
 const (
-	DisplayWidth  int = 64
-	DisplayHeight int = 32
+	LowResWidth  int = 64
+	LowResHeight int = 32
+
+	HighResWidth  int = 128
+	HighResHeight int = 64
+)
+
+const (
+	Plane1 uint8 = 1 << iota
+	Plane2
+
+	// PlaneCount is the number of bitplanes; pixels hold one bit per plane.
+	PlaneCount = 2
 )
 
+// Drawer receives the framebuffer as rows of pixels whenever it changes. Each
+// pixel is a colour index made up of one bit per plane, so it is always 0 or 1
+// unless a program selects the second XO-CHIP plane. The dimensions of pixels
+// follow the current resolution of the display, and the slices are only valid
+// for the duration of the call.
 type Drawer interface {
-	Draw(pixels [DisplayHeight][DisplayWidth]bool) error
+	Draw(pixels [][]uint8) error
 }
 
 type Display struct {
-	pixels [DisplayHeight][DisplayWidth]bool
+	pixels [][]uint8
+	hires  bool
+	planes uint8
 	drawer Drawer
+	wrap   bool
 }
 
-func NewDisplay(drawer Drawer) *Display {
+func NewDisplay(drawer Drawer, wrap bool) *Display {
 	return &Display{
+		pixels: newPixels(LowResWidth, LowResHeight),
+		planes: Plane1,
 		drawer: drawer,
+		wrap:   wrap,
+	}
+}
+
+func newPixels(width, height int) [][]uint8 {
+	pixels := make([][]uint8, height)
+	for y := range pixels {
+		pixels[y] = make([]uint8, width)
+	}
+
+	return pixels
+}
+
+func (d *Display) Width() int {
+	return len(d.pixels[0])
+}
+
+func (d *Display) Height() int {
+	return len(d.pixels)
+}
+
+func (d *Display) HighRes() bool {
+	return d.hires
+}
+
+// Planes returns the mask of planes affected by drawing, clearing and scrolling.
+func (d *Display) Planes() uint8 {
+	return d.planes
+}
+
+// SelectPlanes sets the mask of planes affected by drawing, clearing and scrolling.
+func (d *Display) SelectPlanes(mask uint8) {
+	d.planes = mask & (Plane1 | Plane2)
+}
+
+// SelectedPlaneCount returns the number of currently selected planes.
+func (d *Display) SelectedPlaneCount() int {
+	count := 0
+	for plane := 0; plane < PlaneCount; plane++ {
+		if d.planes&(1<<plane) != 0 {
+			count++
+		}
 	}
+
+	return count
+}
+
+// SetHighRes switches between the 64x32 and 128x64 resolutions, clearing the screen.
+func (d *Display) SetHighRes(hires bool) error {
+	d.hires = hires
+
+	if hires {
+		d.pixels = newPixels(HighResWidth, HighResHeight)
+	} else {
+		d.pixels = newPixels(LowResWidth, LowResHeight)
+	}
+
+	return d.drawer.Draw(d.pixels)
 }
 
 func (d *Display) Clear() error {
 	for y := range d.pixels {
 		for x := range d.pixels[y] {
-			d.pixels[y][x] = false
+			d.pixels[y][x] &^= d.planes
 		}
 	}
 
 	return d.drawer.Draw(d.pixels)
 }
 
+// DrawSprite draws an 8 pixel wide sprite with one byte per row. When more
+// than one plane is selected, sprite holds the data for each selected plane in
+// turn.
 func (d *Display) DrawSprite(x, y uint8, sprite []uint8) (uint8, error) {
-	startX := int(x)
-	startY := int(y)
+	return d.drawPlanes(int(x), int(y), sprite, 1)
+}
+
+// DrawLargeSprite draws a 16x16 sprite with two bytes per row. When more than
+// one plane is selected, sprite holds the data for each selected plane in turn.
+func (d *Display) DrawLargeSprite(x, y uint8, sprite []uint8) (uint8, error) {
+	return d.drawPlanes(int(x), int(y), sprite, 2)
+}
 
+func (d *Display) drawPlanes(startX, startY int, sprite []uint8, bytesPerRow int) (uint8, error) {
 	vf := uint8(0)
 
-	for row := range sprite {
-		if startY+row >= DisplayHeight {
-			break
+	count := d.SelectedPlaneCount()
+	if count == 0 {
+		return vf, d.drawer.Draw(d.pixels)
+	}
+
+	size := len(sprite) / count
+
+	for plane := 0; plane < PlaneCount; plane++ {
+		bit := uint8(1 << plane)
+		if d.planes&bit == 0 {
+			continue
 		}
 
-		for col := 0; col < 8; col++ {
-			if startX+col >= DisplayWidth {
+		vf |= d.drawSprite(startX, startY, sprite[:size], bytesPerRow, bit)
+		sprite = sprite[size:]
+	}
+
+	return vf, d.drawer.Draw(d.pixels)
+}
+
+func (d *Display) drawSprite(startX, startY int, sprite []uint8, bytesPerRow int, bit uint8) uint8 {
+	width := d.Width()
+	height := d.Height()
+
+	vf := uint8(0)
+
+	for row := 0; row < len(sprite)/bytesPerRow; row++ {
+		py := startY + row
+		if py >= height {
+			if !d.wrap {
 				break
 			}
 
-			current := d.pixels[startY+row][startX+col]
-			new := (sprite[row]>>(7-col))&1 != 0
+			py %= height
+		}
+
+		for col := 0; col < 8*bytesPerRow; col++ {
+			px := startX + col
+			if px >= width {
+				if !d.wrap {
+					break
+				}
 
-			if current && new {
-				d.pixels[startY+row][startX+col] = false
+				px %= width
+			}
+
+			if (sprite[row*bytesPerRow+col/8]>>(7-col%8))&1 == 0 {
+				continue
+			}
+
+			if d.pixels[py][px]&bit != 0 {
 				vf = 1
-			} else if !current && new {
-				d.pixels[startY+row][startX+col] = true
 			}
+
+			d.pixels[py][px] ^= bit
 		}
 	}
 
-	return vf, d.drawer.Draw(d.pixels)
+	return vf
+}
+
+// move replaces the selected planes of the pixel at (x, y) with those of the
+// pixel at (fromX, fromY), or clears them if that lies off screen.
+func (d *Display) move(x, y, fromX, fromY int) {
+	source := uint8(0)
+	if fromX >= 0 && fromX < d.Width() && fromY >= 0 && fromY < d.Height() {
+		source = d.pixels[fromY][fromX]
+	}
+
+	d.pixels[y][x] = d.pixels[y][x]&^d.planes | source&d.planes
+}
+
+// ScrollDown moves the screen contents down by n rows.
+func (d *Display) ScrollDown(n int) error {
+	for y := d.Height() - 1; y >= 0; y-- {
+		for x := range d.pixels[y] {
+			d.move(x, y, x, y-n)
+		}
+	}
+
+	return d.drawer.Draw(d.pixels)
+}
+
+// ScrollUp moves the screen contents up by n rows.
+func (d *Display) ScrollUp(n int) error {
+	for y := 0; y < d.Height(); y++ {
+		for x := range d.pixels[y] {
+			d.move(x, y, x, y+n)
+		}
+	}
+
+	return d.drawer.Draw(d.pixels)
+}
+
+// ScrollRight moves the screen contents right by n columns.
+func (d *Display) ScrollRight(n int) error {
+	for y := range d.pixels {
+		for x := d.Width() - 1; x >= 0; x-- {
+			d.move(x, y, x-n, y)
+		}
+	}
+
+	return d.drawer.Draw(d.pixels)
+}
+
+// ScrollLeft moves the screen contents left by n columns.
+func (d *Display) ScrollLeft(n int) error {
+	for y := range d.pixels {
+		for x := 0; x < d.Width(); x++ {
+			d.move(x, y, x+n, y)
+		}
+	}
+
+	return d.drawer.Draw(d.pixels)
+}
+
+// Redraw passes the current framebuffer to the drawer.
+func (d *Display) Redraw() error {
+	return d.drawer.Draw(d.pixels)
+}
+
+// MarshalBinary encodes the resolution, selected planes and pixels.
+func (d *Display) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, 2+d.Width()*d.Height())
+
+	hires := uint8(0)
+	if d.hires {
+		hires = 1
+	}
+
+	b = append(b, hires, d.planes)
+	for y := range d.pixels {
+		b = append(b, d.pixels[y]...)
+	}
+
+	return b, nil
+}
+
+// UnmarshalBinary restores a display encoded by MarshalBinary. It does not
+// redraw; call Redraw once the rest of the machine has been restored.
+func (d *Display) UnmarshalBinary(b []byte) error {
+	if len(b) < 2 {
+		return errors.New("display state too short")
+	}
+
+	hires := b[0] != 0
+
+	width, height := LowResWidth, LowResHeight
+	if hires {
+		width, height = HighResWidth, HighResHeight
+	}
+
+	if len(b) != 2+width*height {
+		return fmt.Errorf("display state has %d bytes, expected %d", len(b), 2+width*height)
+	}
+
+	pixels := newPixels(width, height)
+	for y := range pixels {
+		copy(pixels[y], b[2+y*width:])
+	}
+
+	d.hires = hires
+	d.planes = b[1]
+	d.pixels = pixels
+
+	return nil
 }
@@ -0,0 +1,89 @@
+package chip8
+
+import (
+	"chip8/chip8/opcodes"
+	"fmt"
+)
+
+// Location identifies the instruction that caused a fault.
+type Location struct {
+	PC     uint16
+	Opcode opcodes.Opcode
+}
+
+func (l Location) FaultLocation() Location {
+	return l
+}
+
+// Fault is implemented by the errors Cycle returns when the program does
+// something the machine cannot execute. The PC is left on the faulting
+// instruction and no memory is modified by it.
+type Fault interface {
+	error
+	FaultLocation() Location
+}
+
+// StackOverflowError is returned when 2NNN is executed with a full stack.
+type StackOverflowError struct {
+	Location
+}
+
+func (e *StackOverflowError) Error() string {
+	return fmt.Sprintf("stack overflow at 0x%03X: %04X", e.PC, uint16(e.Opcode))
+}
+
+// StackUnderflowError is returned when 00EE is executed with an empty stack.
+type StackUnderflowError struct {
+	Location
+}
+
+func (e *StackUnderflowError) Error() string {
+	return fmt.Sprintf("stack underflow at 0x%03X: %04X", e.PC, uint16(e.Opcode))
+}
+
+// MemoryOutOfBoundsError is returned when an instruction is fetched from, or
+// reads or writes, memory past the end of the address space.
+type MemoryOutOfBoundsError struct {
+	Location
+	Address int
+	Size    int
+}
+
+func (e *MemoryOutOfBoundsError) Error() string {
+	return fmt.Sprintf("memory access out of bounds at 0x%03X: %04X accessed %d bytes at 0x%03X", e.PC, uint16(e.Opcode), e.Size, e.Address)
+}
+
+// UnknownOpcodeError is returned for opcodes that are not instructions, or
+// are not enabled by the quirks.
+type UnknownOpcodeError struct {
+	Location
+}
+
+func (e *UnknownOpcodeError) Error() string {
+	return fmt.Sprintf("unknown opcode at 0x%03X: %04X", e.PC, uint16(e.Opcode))
+}
+
+// ROMTooLargeError is returned by LoadROMAt when the ROM does not fit in
+// memory after the load address.
+type ROMTooLargeError struct {
+	Size int
+	Max  int
+}
+
+func (e *ROMTooLargeError) Error() string {
+	return fmt.Sprintf("ROM is %d bytes, larger than the %d bytes available", e.Size, e.Max)
+}
+
+func (c *Chip8) location() Location {
+	return Location{PC: c.opcodePC, Opcode: c.opcode}
+}
+
+// checkMemory returns a MemoryOutOfBoundsError unless the size bytes at addr
+// are all within memory.
+func (c *Chip8) checkMemory(addr, size int) error {
+	if addr < 0 || addr+size > len(c.memory) {
+		return &MemoryOutOfBoundsError{Location: c.location(), Address: addr, Size: size}
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package chip8
+
+import (
+	"chip8/chip8/opcodes"
+)
+
+// Access is a kind of memory access, or a set of them.
+type Access uint8
+
+const (
+	AccessRead Access = 1 << iota
+	AccessWrite
+	AccessExecute
+)
+
+func (a Access) String() string {
+	s := ""
+
+	for _, kind := range []struct {
+		access Access
+		name   string
+	}{{AccessRead, "r"}, {AccessWrite, "w"}, {AccessExecute, "x"}} {
+		if a&kind.access != 0 {
+			s += kind.name
+		}
+	}
+
+	return s
+}
+
+// MemoryAccess describes a single byte of memory accessed by an instruction.
+type MemoryAccess struct {
+	Access Access
+
+	// PC and Opcode identify the instruction making the access.
+	PC     uint16
+	Opcode opcodes.Opcode
+
+	Address uint16
+	// Old and New are the values before and after a write. They are equal
+	// for reads and executes.
+	Old uint8
+	New uint8
+}
+
+// SetMemoryHook sets a function called for every byte of memory fetched,
+// read or written by an instruction. Pass nil to remove it.
+func (c *Chip8) SetMemoryHook(hook func(MemoryAccess)) {
+	c.memoryHook = hook
+}
+
+func (c *Chip8) notify(access Access, addr uint16, old, new uint8) {
+	if c.memoryHook == nil {
+		return
+	}
+
+	c.memoryHook(MemoryAccess{
+		Access:  access,
+		PC:      c.opcodePC,
+		Opcode:  c.opcode,
+		Address: addr,
+		Old:     old,
+		New:     new,
+	})
+}
+
+// fetched notifies the hook of the n instruction bytes fetched from addr.
+func (c *Chip8) fetched(addr uint16, n int) {
+	if c.memoryHook == nil {
+		return
+	}
+
+	for i, v := range c.memory[addr : int(addr)+n] {
+		c.notify(AccessExecute, addr+uint16(i), v, v)
+	}
+}
+
+func (c *Chip8) read(addr uint16) uint8 {
+	v := c.memory[addr]
+	c.notify(AccessRead, addr, v, v)
+
+	return v
+}
+
+// readSlice returns n bytes of memory starting at addr. The slice must not be
+// modified.
+func (c *Chip8) readSlice(addr uint16, n int) []uint8 {
+	b := c.memory[addr : int(addr)+n]
+
+	if c.memoryHook != nil {
+		for i, v := range b {
+			c.notify(AccessRead, addr+uint16(i), v, v)
+		}
+	}
+
+	return b
+}
+
+func (c *Chip8) write(addr uint16, v uint8) {
+	old := c.memory[addr]
+	c.memory[addr] = v
+
+	c.notify(AccessWrite, addr, old, v)
+}
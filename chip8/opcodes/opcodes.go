@@ -6,13 +6,22 @@ type Instruction int
 
 const (
 	InstructionUnknown Instruction = iota
+	Instruction00CN
+	Instruction00DN
 	Instruction00E0
 	Instruction00EE
+	Instruction00FB
+	Instruction00FC
+	Instruction00FD
+	Instruction00FE
+	Instruction00FF
 	Instruction1NNN
 	Instruction2NNN
 	Instruction3XNN
 	Instruction4XNN
 	Instruction5XY0
+	Instruction5XY2
+	Instruction5XY3
 	Instruction6XNN
 	Instruction7XNN
 	Instruction8XY0
@@ -29,17 +38,25 @@ const (
 	InstructionBNNN
 	InstructionCXNN
 	InstructionDXYN
+	InstructionDXY0
 	InstructionEX9E
 	InstructionEXA1
+	InstructionF000
+	InstructionFN01
+	InstructionF002
 	InstructionFX07
 	InstructionFX0A
 	InstructionFX15
 	InstructionFX18
 	InstructionFX1E
 	InstructionFX29
+	InstructionFX30
 	InstructionFX33
+	InstructionFX3A
 	InstructionFX55
 	InstructionFX65
+	InstructionFX75
+	InstructionFX85
 )
 
 type Opcode uint16
@@ -47,11 +64,29 @@ type Opcode uint16
 func (o Opcode) Instruction() Instruction {
 	switch uint16(o) & 0xF000 {
 	case 0x0000:
-		switch o.NN() {
-		case 0xE0: // clear screen
+		if uint16(o)&0xFFF0 == 0x00C0 { // scroll down
+			return Instruction00CN
+		}
+
+		if uint16(o)&0xFFF0 == 0x00D0 { // scroll up
+			return Instruction00DN
+		}
+
+		switch uint16(o) {
+		case 0x00E0: // clear screen
 			return Instruction00E0
-		case 0xEE: // return
+		case 0x00EE: // return
 			return Instruction00EE
+		case 0x00FB: // scroll right
+			return Instruction00FB
+		case 0x00FC: // scroll left
+			return Instruction00FC
+		case 0x00FD: // exit
+			return Instruction00FD
+		case 0x00FE: // low resolution
+			return Instruction00FE
+		case 0x00FF: // high resolution
+			return Instruction00FF
 		}
 	case 0x1000: // jump
 		return Instruction1NNN
@@ -61,9 +96,14 @@ func (o Opcode) Instruction() Instruction {
 		return Instruction3XNN
 	case 0x4000: // skip
 		return Instruction4XNN
-	case 0x5000: // skip?
-		if o.N() == 0 {
+	case 0x5000:
+		switch o.N() {
+		case 0x0: // skip?
 			return Instruction5XY0
+		case 0x2: // save range
+			return Instruction5XY2
+		case 0x3: // load range
+			return Instruction5XY3
 		}
 	case 0x6000: // set
 		return Instruction6XNN
@@ -101,6 +141,10 @@ func (o Opcode) Instruction() Instruction {
 	case 0xC000: // random
 		return InstructionCXNN
 	case 0xD000: // display
+		if o.N() == 0 {
+			return InstructionDXY0
+		}
+
 		return InstructionDXYN
 	case 0xE000: // skip if key
 		switch o.NN() {
@@ -110,7 +154,16 @@ func (o Opcode) Instruction() Instruction {
 			return InstructionEXA1
 		}
 	case 0xF000:
+		switch uint16(o) {
+		case 0xF000: // long index
+			return InstructionF000
+		case 0xF002: // audio pattern
+			return InstructionF002
+		}
+
 		switch o.NN() {
+		case 0x01: // select planes
+			return InstructionFN01
 		// timers
 		case 0x07:
 			return InstructionFX07
@@ -124,12 +177,20 @@ func (o Opcode) Instruction() Instruction {
 			return InstructionFX0A
 		case 0x29: // font char
 			return InstructionFX29
+		case 0x30: // large font char
+			return InstructionFX30
 		case 0x33: // decimal conversion
 			return InstructionFX33
+		case 0x3A: // pitch
+			return InstructionFX3A
 		case 0x55: // store
 			return InstructionFX55
 		case 0x65: // load
 			return InstructionFX65
+		case 0x75: // store flags
+			return InstructionFX75
+		case 0x85: // load flags
+			return InstructionFX85
 		}
 	}
 
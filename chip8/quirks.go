@@ -0,0 +1,153 @@
+package chip8
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IndexIncrement controls how FX55/FX65 modify I after a store or load.
+type IndexIncrement int
+
+const (
+	// IndexUnchanged leaves I untouched (SUPER-CHIP 1.1).
+	IndexUnchanged IndexIncrement = iota
+	// IndexIncrementX adds X to I (CHIP-48).
+	IndexIncrementX
+	// IndexIncrementXPlusOne adds X+1 to I (COSMAC VIP, XO-CHIP).
+	IndexIncrementXPlusOne
+)
+
+// Quirks selects between the incompatible interpretations of the ambiguous
+// opcodes that different CHIP-8 implementations have shipped over the years.
+type Quirks struct {
+	// ShiftUsesVY makes 8XY6/8XYE shift VY into VX rather than shifting VX in place.
+	ShiftUsesVY bool
+	// JumpUsesVX makes BNNN jump to XNN + VX rather than NNN + V0.
+	JumpUsesVX bool
+	// IndexIncrement controls how FX55/FX65 modify I.
+	IndexIncrement IndexIncrement
+	// LogicResetsVF makes 8XY1/8XY2/8XY3 set VF to 0.
+	LogicResetsVF bool
+	// WrapSprites makes DXYN wrap pixels around the screen edges instead of clipping them.
+	WrapSprites bool
+	// DisplayWait makes DXYN wait for the vertical blank, limiting draws to one per frame.
+	DisplayWait bool
+	// SuperChip enables the SUPER-CHIP 1.1 instructions: high resolution,
+	// scrolling, large sprites, exit and the RPL flags.
+	SuperChip bool
+	// XOChip enables the XO-CHIP extensions: 64K of memory, a second bitplane
+	// and audio patterns. It implies SuperChip.
+	XOChip bool
+}
+
+var (
+	// QuirksLegacy matches this interpreter before its quirks could be
+	// configured, and is the default for ROMs with no settings. Shifts use VY,
+	// FX55/FX65 leave I alone, sprites clip and draws don't wait.
+	QuirksLegacy = Quirks{
+		ShiftUsesVY:    true,
+		IndexIncrement: IndexUnchanged,
+	}
+
+	// QuirksVIP matches the original COSMAC VIP interpreter.
+	QuirksVIP = Quirks{
+		ShiftUsesVY:    true,
+		IndexIncrement: IndexIncrementXPlusOne,
+		LogicResetsVF:  true,
+		DisplayWait:    true,
+	}
+
+	// QuirksCHIP48 matches CHIP-48 on the HP-48 calculators.
+	QuirksCHIP48 = Quirks{
+		JumpUsesVX:     true,
+		IndexIncrement: IndexIncrementX,
+	}
+
+	// QuirksSuperChip matches SUPER-CHIP 1.1.
+	QuirksSuperChip = Quirks{
+		JumpUsesVX:     true,
+		IndexIncrement: IndexUnchanged,
+		SuperChip:      true,
+	}
+
+	// QuirksXOChip matches XO-CHIP as implemented by Octo.
+	QuirksXOChip = Quirks{
+		ShiftUsesVY:    true,
+		IndexIncrement: IndexIncrementXPlusOne,
+		WrapSprites:    true,
+		SuperChip:      true,
+		XOChip:         true,
+	}
+)
+
+// BinaryQuirks is the fixed-size form of Quirks that save states and movies
+// store with encoding/binary.
+type BinaryQuirks struct {
+	ShiftUsesVY    bool
+	JumpUsesVX     bool
+	IndexIncrement uint8
+	LogicResetsVF  bool
+	WrapSprites    bool
+	DisplayWait    bool
+	SuperChip      bool
+	XOChip         bool
+}
+
+// Binary returns q in its fixed-size form.
+func (q Quirks) Binary() BinaryQuirks {
+	return BinaryQuirks{
+		ShiftUsesVY:    q.ShiftUsesVY,
+		JumpUsesVX:     q.JumpUsesVX,
+		IndexIncrement: uint8(q.IndexIncrement),
+		LogicResetsVF:  q.LogicResetsVF,
+		WrapSprites:    q.WrapSprites,
+		DisplayWait:    q.DisplayWait,
+		SuperChip:      q.SuperChip,
+		XOChip:         q.XOChip,
+	}
+}
+
+// Quirks returns the quirks b holds.
+func (b BinaryQuirks) Quirks() Quirks {
+	return Quirks{
+		ShiftUsesVY:    b.ShiftUsesVY,
+		JumpUsesVX:     b.JumpUsesVX,
+		IndexIncrement: IndexIncrement(b.IndexIncrement),
+		LogicResetsVF:  b.LogicResetsVF,
+		WrapSprites:    b.WrapSprites,
+		DisplayWait:    b.DisplayWait,
+		SuperChip:      b.SuperChip,
+		XOChip:         b.XOChip,
+	}
+}
+
+var quirksProfiles = map[string]Quirks{
+	"legacy":    QuirksLegacy,
+	"vip":       QuirksVIP,
+	"chip48":    QuirksCHIP48,
+	"superchip": QuirksSuperChip,
+	"xochip":    QuirksXOChip,
+}
+
+// QuirksProfiles returns the names accepted by QuirksByName in sorted order.
+func QuirksProfiles() []string {
+	names := make([]string, 0, len(quirksProfiles))
+	for name := range quirksProfiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// QuirksByName returns the named quirks profile.
+func QuirksByName(name string) (Quirks, error) {
+	q, ok := quirksProfiles[strings.ToLower(name)]
+	if !ok {
+		return Quirks{}, fmt.Errorf("unknown quirks profile %q, expected one of %s", name, strings.Join(QuirksProfiles(), ", "))
+	}
+
+	return q, nil
+}
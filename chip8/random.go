@@ -0,0 +1,70 @@
+package chip8
+
+import (
+	"errors"
+	"math/rand"
+)
+
+// Random supplies the random numbers CXNN masks with NN.
+type Random interface {
+	Byte() uint8
+	// Seed restarts the sequence, so that a run can be repeated exactly.
+	Seed(seed int64)
+}
+
+type sourceRandom struct {
+	*rand.Rand
+}
+
+// NewRandom returns a Random drawing bytes from src.
+func NewRandom(src rand.Source) Random {
+	return sourceRandom{rand.New(src)}
+}
+
+func (r sourceRandom) Byte() uint8 {
+	return uint8(r.Intn(256))
+}
+
+// vipInterpreterSize is the size of the COSMAC VIP's CHIP-8 interpreter,
+// which occupies memory up to DefaultLoadAddress.
+const vipInterpreterSize = DefaultLoadAddress
+
+// ErrVIPInterpreter is returned by NewVIPRandom for an image of the wrong size.
+var ErrVIPInterpreter = errors.New("VIP interpreter image must be 512 bytes")
+
+// VIPRandom follows the COSMAC VIP interpreter's random number routine, for
+// comparing programs against the original machine. The routine keeps a
+// 16-bit seed: it counts the low byte up, then adds the interpreter byte in
+// page 1 that the high byte points at, and keeps the sum as the new high
+// byte. The numbers are only as good as the VIP's, which is to say poor.
+type VIPRandom struct {
+	page   [256]uint8
+	hi, lo uint8
+}
+
+// NewVIPRandom returns a VIPRandom reading from interpreter, an image of the
+// VIP's CHIP-8 interpreter from address 0. The image is not distributed with
+// this package.
+func NewVIPRandom(interpreter []byte) (*VIPRandom, error) {
+	if len(interpreter) != vipInterpreterSize {
+		return nil, ErrVIPInterpreter
+	}
+
+	r := &VIPRandom{}
+	copy(r.page[:], interpreter[0x100:])
+
+	return r, nil
+}
+
+func (r *VIPRandom) Byte() uint8 {
+	r.lo++
+	r.hi = r.page[r.hi] + r.lo
+
+	return r.hi
+}
+
+// Seed sets the routine's 16-bit seed from the low 16 bits of seed.
+func (r *VIPRandom) Seed(seed int64) {
+	r.hi = uint8(seed >> 8)
+	r.lo = uint8(seed)
+}
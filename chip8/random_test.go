@@ -0,0 +1,74 @@
+package chip8
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockRandom struct {
+	mock.Mock
+}
+
+func (m *MockRandom) Byte() uint8 {
+	args := m.Called()
+	return args.Get(0).(uint8)
+}
+
+func (m *MockRandom) Seed(seed int64) {
+	m.Called(seed)
+}
+
+type RandomSuite struct {
+	suite.Suite
+}
+
+func (suite *RandomSuite) TestMasksRandomByte() {
+	random := new(MockRandom)
+	random.On("Byte").Return(uint8(0xB6))
+
+	c, err := New(new(MockKeys), new(MockBeeper), new(MockDrawer), QuirksVIP, random)
+	suite.Require().Nil(err)
+	suite.Require().Nil(c.LoadROM(bytes.NewReader([]byte{0xC3, 0x0F})))
+
+	suite.Require().Nil(c.Cycle())
+	suite.Assert().Equal(uint8(0x06), c.v[3])
+	random.AssertExpectations(suite.T())
+}
+
+func (suite *RandomSuite) TestSeed() {
+	a := NewRandom(rand.NewSource(1))
+	b := NewRandom(rand.NewSource(2))
+	b.Seed(1)
+
+	for i := 0; i < 16; i++ {
+		suite.Assert().Equal(a.Byte(), b.Byte())
+	}
+}
+
+func (suite *RandomSuite) TestVIPRandom() {
+	_, err := NewVIPRandom(make([]byte, 256))
+	suite.Assert().ErrorIs(err, ErrVIPInterpreter)
+
+	interpreter := make([]byte, vipInterpreterSize)
+	interpreter[0x100] = 0x10
+	interpreter[0x111] = 0x20
+
+	r, err := NewVIPRandom(interpreter)
+	suite.Require().Nil(err)
+
+	// The low byte counts up and the high byte picks the next table entry.
+	suite.Assert().Equal(uint8(0x11), r.Byte())
+	suite.Assert().Equal(uint8(0x22), r.Byte())
+	suite.Assert().Equal(uint8(0x03), r.Byte())
+
+	r.Seed(0x0000)
+	suite.Assert().Equal(uint8(0x11), r.Byte())
+}
+
+func TestRandomSuite(t *testing.T) {
+	suite.Run(t, new(RandomSuite))
+}
@@ -0,0 +1,183 @@
+package chip8
+
+import (
+	"chip8/chip8/display"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const stateVersion = 2
+
+// maxDisplayStateSize is the size of the largest display MarshalBinary
+// writes: two header bytes, then a byte for each high resolution pixel.
+const maxDisplayStateSize = 2 + display.HighResWidth*display.HighResHeight
+
+var stateMagic = [4]byte{'C', '8', 'S', 'T'}
+
+var (
+	ErrInvalidState   = errors.New("not a chip8 save state")
+	ErrStateVersion   = errors.New("unsupported save state version")
+	ErrROMMismatch    = errors.New("save state was made for a different ROM")
+	ErrQuirksMismatch = errors.New("save state was made with different quirks")
+)
+
+type stateHeader struct {
+	Magic   [4]byte
+	Version uint16
+	Quirks  BinaryQuirks
+	ROMHash [sha1.Size]byte
+}
+
+type stateMachine struct {
+	V  [16]uint8
+	I  uint16
+	PC uint16
+
+	Stack [16]uint16
+	SP    uint16
+
+	DelayTimer uint8
+	SoundTimer uint8
+
+	Pattern [16]uint8
+	Pitch   uint8
+
+	RPL [16]uint8
+
+	VBlank bool
+	Halted bool
+
+	MemorySize  uint32
+	DisplaySize uint32
+}
+
+// SaveState writes a snapshot of the whole machine to w. The snapshot records
+// the quirks and a hash of the loaded ROM so that it can only be restored into
+// a compatible machine. The random number source is not saved, so CXNN
+// carries on from wherever it is when the snapshot is loaded.
+func (c *Chip8) SaveState(w io.Writer) error {
+	header := stateHeader{
+		Magic:   stateMagic,
+		Version: stateVersion,
+		Quirks:  c.quirks.Binary(),
+		ROMHash: c.romHash,
+	}
+
+	display, err := c.display.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("failed to save display: %v", err)
+	}
+
+	machine := stateMachine{
+		V:  c.v,
+		I:  c.i,
+		PC: c.pc,
+
+		Stack: c.stack,
+		SP:    c.sp,
+
+		DelayTimer: c.delayTimer,
+		SoundTimer: c.soundTimer,
+
+		Pattern: c.pattern,
+		Pitch:   c.pitch,
+
+		RPL: c.rpl,
+
+		VBlank: c.vblank,
+		Halted: c.halted,
+
+		MemorySize:  uint32(len(c.memory)),
+		DisplaySize: uint32(len(display)),
+	}
+
+	for _, data := range []interface{}{header, machine, c.memory, display} {
+		if err := binary.Write(w, binary.BigEndian, data); err != nil {
+			return fmt.Errorf("failed to save state: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// LoadState restores a snapshot written by SaveState. The machine is left
+// untouched if the snapshot is invalid or was made for a different ROM or
+// different quirks.
+func (c *Chip8) LoadState(r io.Reader) error {
+	var header stateHeader
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return fmt.Errorf("failed to read state header: %w", ErrInvalidState)
+	}
+
+	if header.Magic != stateMagic {
+		return ErrInvalidState
+	}
+
+	if header.Version != stateVersion {
+		return fmt.Errorf("%w: %d", ErrStateVersion, header.Version)
+	}
+
+	if header.ROMHash != c.romHash {
+		return fmt.Errorf("%w: state is for ROM %x, loaded ROM is %x", ErrROMMismatch, header.ROMHash, c.romHash)
+	}
+
+	if header.Quirks != c.quirks.Binary() {
+		return ErrQuirksMismatch
+	}
+
+	var machine stateMachine
+	if err := binary.Read(r, binary.BigEndian, &machine); err != nil {
+		return fmt.Errorf("failed to read machine state: %w", ErrInvalidState)
+	}
+
+	if int(machine.MemorySize) != len(c.memory) || int(machine.SP) > len(machine.Stack) || int(machine.DisplaySize) > maxDisplayStateSize {
+		return ErrInvalidState
+	}
+
+	memory := make([]uint8, machine.MemorySize)
+	if _, err := io.ReadFull(r, memory); err != nil {
+		return fmt.Errorf("failed to read memory: %w", ErrInvalidState)
+	}
+
+	display := make([]byte, machine.DisplaySize)
+	if _, err := io.ReadFull(r, display); err != nil {
+		return fmt.Errorf("failed to read display: %w", ErrInvalidState)
+	}
+
+	if err := c.display.UnmarshalBinary(display); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidState, err)
+	}
+
+	c.v = machine.V
+	c.i = machine.I
+	c.pc = machine.PC
+
+	c.stack = machine.Stack
+	c.sp = machine.SP
+
+	c.delayTimer = machine.DelayTimer
+	c.soundTimer = machine.SoundTimer
+
+	c.pattern = machine.Pattern
+	c.pitch = machine.Pitch
+
+	c.rpl = machine.RPL
+
+	c.vblank = machine.VBlank
+	c.halted = machine.Halted
+
+	copy(c.memory, memory)
+
+	if c.quirks.XOChip {
+		c.setPattern()
+	}
+
+	if err := c.display.Redraw(); err != nil {
+		return fmt.Errorf("failed to redraw display: %v", err)
+	}
+
+	return nil
+}
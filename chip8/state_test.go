@@ -0,0 +1,94 @@
+package chip8
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type StateSuite struct {
+	suite.Suite
+}
+
+func (suite *StateSuite) TestRoundTrip() {
+	program := []uint16{0x6A2A, 0xA300, 0x2208, 0x0000, 0x6B07, 0xFB18, 0xD005, 0x00EE}
+
+	c, err := newTestChip8(QuirksVIP, program...)
+	suite.Require().Nil(err)
+
+	for i := 0; i < 5; i++ {
+		suite.Require().Nil(c.Cycle())
+	}
+
+	var state bytes.Buffer
+	suite.Require().Nil(c.SaveState(&state))
+
+	restored, err := newTestChip8(QuirksVIP, program...)
+	suite.Require().Nil(err)
+	suite.Require().Nil(restored.LoadState(&state))
+
+	suite.Assert().Equal(c.v, restored.v)
+	suite.Assert().Equal(c.i, restored.i)
+	suite.Assert().Equal(c.pc, restored.pc)
+	suite.Assert().Equal(c.stack, restored.stack)
+	suite.Assert().Equal(c.sp, restored.sp)
+	suite.Assert().Equal(c.soundTimer, restored.soundTimer)
+	suite.Assert().Equal(c.memory, restored.memory)
+}
+
+func (suite *StateSuite) TestDifferentROM() {
+	c, err := newTestChip8(QuirksVIP, 0x6001)
+	suite.Require().Nil(err)
+
+	var state bytes.Buffer
+	suite.Require().Nil(c.SaveState(&state))
+
+	other, err := newTestChip8(QuirksVIP, 0x6002)
+	suite.Require().Nil(err)
+
+	err = other.LoadState(&state)
+	suite.Assert().ErrorIs(err, ErrROMMismatch)
+}
+
+func (suite *StateSuite) TestDifferentQuirks() {
+	c, err := newTestChip8(QuirksVIP, 0x6001)
+	suite.Require().Nil(err)
+
+	var state bytes.Buffer
+	suite.Require().Nil(c.SaveState(&state))
+
+	other, err := newTestChip8(QuirksSuperChip, 0x6001)
+	suite.Require().Nil(err)
+
+	err = other.LoadState(&state)
+	suite.Assert().ErrorIs(err, ErrQuirksMismatch)
+}
+
+func (suite *StateSuite) TestInvalidState() {
+	c, err := newTestChip8(QuirksVIP, 0x6001)
+	suite.Require().Nil(err)
+
+	err = c.LoadState(bytes.NewReader([]byte("not a state")))
+	suite.Assert().ErrorIs(err, ErrInvalidState)
+}
+
+func (suite *StateSuite) TestDisplaySizeTooLarge() {
+	c, err := newTestChip8(QuirksVIP, 0x6001)
+	suite.Require().Nil(err)
+
+	var state bytes.Buffer
+	suite.Require().Nil(c.SaveState(&state))
+
+	b := state.Bytes()
+	offset := binary.Size(stateHeader{}) + binary.Size(stateMachine{}) - 4
+	binary.BigEndian.PutUint32(b[offset:], 0xFFFFFFFF)
+
+	err = c.LoadState(bytes.NewReader(b))
+	suite.Assert().ErrorIs(err, ErrInvalidState)
+}
+
+func TestState(t *testing.T) {
+	suite.Run(t, new(StateSuite))
+}
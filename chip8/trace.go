@@ -0,0 +1,33 @@
+package chip8
+
+import (
+	"chip8/chip8/opcodes"
+	"encoding/binary"
+)
+
+// Step describes a single executed instruction.
+type Step struct {
+	PC     uint16
+	Opcode opcodes.Opcode
+	// Long is the word following an XO-CHIP F000 instruction.
+	Long uint16
+
+	Before Registers
+	After  Registers
+}
+
+// SetTraceHook sets a function called after every instruction is executed.
+// Pass nil to remove it.
+func (c *Chip8) SetTraceHook(hook func(Step)) {
+	c.traceHook = hook
+}
+
+func (c *Chip8) trace(before Registers, opcode opcodes.Opcode) {
+	step := Step{PC: before.PC, Opcode: opcode, Before: before, After: c.Registers()}
+
+	if opcode.Instruction() == opcodes.InstructionF000 && int(before.PC)+4 <= len(c.memory) {
+		step.Long = binary.BigEndian.Uint16(c.memory[before.PC+2:])
+	}
+
+	c.traceHook(step)
+}
@@ -0,0 +1,367 @@
+// Package database looks up the settings a ROM needs by its SHA-1 hash in a
+// copy of the CHIP-8 community database
+// (https://github.com/chip-8/chip-8-database), which is embedded in the
+// binary. Run `make database` to update the copy in data/.
+package database
+
+import (
+	"chip8/chip8"
+	"crypto/sha1"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//go:embed data/*.json
+var data embed.FS
+
+// quirks are the quirks of a platform. Pointers distinguish quirks a ROM
+// leaves unchanged from ones it turns off.
+type quirks struct {
+	Shift                 *bool `json:"shift,omitempty"`
+	MemoryIncrementByX    *bool `json:"memoryIncrementByX,omitempty"`
+	MemoryLeaveIUnchanged *bool `json:"memoryLeaveIUnchanged,omitempty"`
+	Wrap                  *bool `json:"wrap,omitempty"`
+	Jump                  *bool `json:"jump,omitempty"`
+	VBlank                *bool `json:"vblank,omitempty"`
+	Logic                 *bool `json:"logic,omitempty"`
+}
+
+// merge returns q with the quirks set in o replaced.
+func (q quirks) merge(o quirks) quirks {
+	for _, f := range []struct{ dst, src **bool }{
+		{&q.Shift, &o.Shift},
+		{&q.MemoryIncrementByX, &o.MemoryIncrementByX},
+		{&q.MemoryLeaveIUnchanged, &o.MemoryLeaveIUnchanged},
+		{&q.Wrap, &o.Wrap},
+		{&q.Jump, &o.Jump},
+		{&q.VBlank, &o.VBlank},
+		{&q.Logic, &o.Logic},
+	} {
+		if *f.src != nil {
+			*f.dst = *f.src
+		}
+	}
+
+	return q
+}
+
+func isSet(b *bool) bool {
+	return b != nil && *b
+}
+
+// superChipPlatforms run the SUPER-CHIP 1.1 instructions.
+var superChipPlatforms = map[string]bool{
+	"superchip1": true,
+	"superchip":  true,
+	"xochip":     true,
+}
+
+func (q quirks) chip8(platform string) chip8.Quirks {
+	c := chip8.Quirks{
+		ShiftUsesVY:    !isSet(q.Shift),
+		JumpUsesVX:     isSet(q.Jump),
+		IndexIncrement: chip8.IndexIncrementXPlusOne,
+		LogicResetsVF:  isSet(q.Logic),
+		WrapSprites:    isSet(q.Wrap),
+		DisplayWait:    isSet(q.VBlank),
+		SuperChip:      superChipPlatforms[platform],
+		XOChip:         platform == "xochip",
+	}
+
+	switch {
+	case isSet(q.MemoryLeaveIUnchanged):
+		c.IndexIncrement = chip8.IndexUnchanged
+	case isSet(q.MemoryIncrementByX):
+		c.IndexIncrement = chip8.IndexIncrementX
+	}
+
+	return c
+}
+
+type platform struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	DefaultTickrate int    `json:"defaultTickrate"`
+	Quirks          quirks `json:"quirks"`
+}
+
+type colors struct {
+	Pixels []string `json:"pixels"`
+}
+
+// rom is an entry in a program's roms, or in the overrides file.
+type rom struct {
+	// Title is only used in the overrides file.
+	Title string `json:"title"`
+
+	Platforms       []string          `json:"platforms"`
+	QuirkyPlatforms map[string]quirks `json:"quirkyPlatforms"`
+	Tickrate        int               `json:"tickrate"`
+	StartAddress    int               `json:"startAddress"`
+	Colors          *colors           `json:"colors"`
+	Keys            map[string]uint8  `json:"keys"`
+}
+
+// merge returns r with the settings in o replaced.
+func (r rom) merge(o rom) rom {
+	if o.Title != "" {
+		r.Title = o.Title
+	}
+
+	if o.Platforms != nil {
+		r.Platforms = o.Platforms
+	}
+
+	if o.QuirkyPlatforms != nil {
+		q := make(map[string]quirks)
+		for id, p := range r.QuirkyPlatforms {
+			q[id] = p
+		}
+
+		for id, p := range o.QuirkyPlatforms {
+			q[id] = q[id].merge(p)
+		}
+
+		r.QuirkyPlatforms = q
+	}
+
+	if o.Tickrate != 0 {
+		r.Tickrate = o.Tickrate
+	}
+
+	if o.StartAddress != 0 {
+		r.StartAddress = o.StartAddress
+	}
+
+	if o.Colors != nil {
+		r.Colors = o.Colors
+	}
+
+	if o.Keys != nil {
+		k := make(map[string]uint8)
+		for name, key := range r.Keys {
+			k[name] = key
+		}
+
+		for name, key := range o.Keys {
+			k[name] = key
+		}
+
+		r.Keys = k
+	}
+
+	return r
+}
+
+type program struct {
+	Title   string         `json:"title"`
+	Authors []string       `json:"authors"`
+	ROMs    map[string]rom `json:"roms"`
+}
+
+// Entry is the settings for a ROM.
+type Entry struct {
+	Title   string
+	Authors []string
+
+	// Platform is the ID of the platform the settings are for, such as
+	// originalChip8 or superchip.
+	Platform string
+	Quirks   chip8.Quirks
+	// Tickrate is the number of instructions to run per frame.
+	Tickrate     int
+	StartAddress uint16
+	// Palette holds the colours for pixel values 0 to 3. It may hold fewer
+	// than four if the ROM only specifies some.
+	Palette []color.RGBA
+	// Keys maps the names of inputs, such as up or a, to CHIP-8 keys.
+	Keys map[string]uint8
+}
+
+type Database struct {
+	programs  []program
+	hashes    map[string]int
+	platforms map[string]platform
+	overrides map[string]rom
+}
+
+func decode(fsys fs.FS, name string, v interface{}) error {
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", name, err)
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %v", name, err)
+	}
+
+	return nil
+}
+
+// New returns the embedded database.
+func New() (*Database, error) {
+	fsys, err := fs.Sub(data, "data")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+
+	return load(fsys)
+}
+
+// load reads a database laid out as in data/.
+func load(fsys fs.FS) (*Database, error) {
+	d := &Database{
+		platforms: make(map[string]platform),
+		overrides: make(map[string]rom),
+	}
+
+	var platforms []platform
+	if err := decode(fsys, "platforms.json", &platforms); err != nil {
+		return nil, err
+	}
+
+	for _, p := range platforms {
+		d.platforms[p.ID] = p
+	}
+
+	if err := decode(fsys, "programs.json", &d.programs); err != nil {
+		return nil, err
+	}
+
+	if err := decode(fsys, "sha1-hashes.json", &d.hashes); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// LoadOverrides reads user settings from filename, replacing or adding to
+// the database. The file holds an object of ROM entries keyed by SHA-1 hash,
+// in the same form as the database with an optional title:
+//
+//	{"0123...": {"title": "My Game", "platforms": ["superchip"], "tickrate": 20}}
+//
+// A missing file is not an error.
+func (d *Database) LoadOverrides(filename string) error {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to read overrides: %v", err)
+	}
+
+	var overrides map[string]rom
+	if err := json.Unmarshal(b, &overrides); err != nil {
+		return fmt.Errorf("failed to decode overrides %s: %v", filename, err)
+	}
+
+	for hash, r := range overrides {
+		hash = strings.ToLower(hash)
+		d.overrides[hash] = d.overrides[hash].merge(r)
+	}
+
+	return nil
+}
+
+// Lookup returns the settings for the ROM with the given hash, or nil if it
+// is not in the database.
+func (d *Database) Lookup(hash [sha1.Size]byte) (*Entry, error) {
+	key := hex.EncodeToString(hash[:])
+
+	var (
+		p     program
+		r     rom
+		found bool
+	)
+
+	if i, ok := d.hashes[key]; ok && i >= 0 && i < len(d.programs) {
+		p = d.programs[i]
+		r, found = p.ROMs[key]
+	}
+
+	if o, ok := d.overrides[key]; ok {
+		r = r.merge(o)
+		found = true
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	if r.Title == "" {
+		r.Title = p.Title
+	}
+
+	return d.entry(p, r)
+}
+
+func (d *Database) entry(p program, r rom) (*Entry, error) {
+	platforms := r.Platforms
+	if len(platforms) == 0 {
+		platforms = []string{"originalChip8"}
+	}
+
+	// The platforms are in order of preference; use the first one supported.
+	var plat platform
+	for _, id := range platforms {
+		if pl, ok := d.platforms[id]; ok {
+			plat = pl
+			break
+		}
+	}
+
+	if plat.ID == "" {
+		return nil, fmt.Errorf("%s needs an unsupported platform: %s", r.Title, strings.Join(platforms, ", "))
+	}
+
+	e := &Entry{
+		Title:    r.Title,
+		Authors:  p.Authors,
+		Platform: plat.ID,
+		Quirks:   plat.Quirks.merge(r.QuirkyPlatforms[plat.ID]).chip8(plat.ID),
+		Tickrate: r.Tickrate,
+		Keys:     r.Keys,
+	}
+
+	if e.Tickrate == 0 {
+		e.Tickrate = plat.DefaultTickrate
+	}
+
+	if r.StartAddress < 0 || r.StartAddress > 0xFFFF {
+		return nil, fmt.Errorf("%s has an invalid start address: %d", r.Title, r.StartAddress)
+	}
+
+	e.StartAddress = uint16(r.StartAddress)
+
+	if r.Colors != nil {
+		for _, s := range r.Colors.Pixels {
+			c, err := parseColor(s)
+			if err != nil {
+				return nil, fmt.Errorf("%s has an invalid colour: %v", r.Title, err)
+			}
+
+			e.Palette = append(e.Palette, c)
+		}
+	}
+
+	return e, nil
+}
+
+func parseColor(s string) (color.RGBA, error) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 32)
+	if err != nil || len(s) != 7 || s[0] != '#' {
+		return color.RGBA{}, fmt.Errorf("%q is not #RRGGBB", s)
+	}
+
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, nil
+}
@@ -0,0 +1,206 @@
+package database
+
+import (
+	"chip8/chip8"
+	"crypto/sha1"
+	"encoding/hex"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/suite"
+)
+
+var (
+	romHash = sha1.Sum([]byte{0x12, 0x00})
+	romKey  = hex.EncodeToString(romHash[:])
+)
+
+type DatabaseSuite struct {
+	suite.Suite
+
+	database *Database
+	dir      string
+}
+
+func (suite *DatabaseSuite) SetupTest() {
+	d, err := New()
+	suite.Require().Nil(err)
+
+	f := false
+	d.programs = []program{{
+		Title:   "Test",
+		Authors: []string{"Someone"},
+		ROMs: map[string]rom{
+			romKey: {
+				Platforms:       []string{"megachip8", "superchip"},
+				QuirkyPlatforms: map[string]quirks{"superchip": {Jump: &f}},
+				StartAddress:    0x200,
+				Colors:          &colors{Pixels: []string{"#000000", "#FF8000"}},
+				Keys:            map[string]uint8{"up": 5, "a": 6},
+			},
+		},
+	}}
+	d.hashes = map[string]int{romKey: 0}
+
+	suite.database = d
+
+	suite.dir, err = ioutil.TempDir("", "database")
+	suite.Require().Nil(err)
+}
+
+func (suite *DatabaseSuite) TearDownTest() {
+	os.RemoveAll(suite.dir)
+}
+
+func (suite *DatabaseSuite) TestLookup() {
+	e, err := suite.database.Lookup(romHash)
+	suite.Require().Nil(err)
+	suite.Require().NotNil(e)
+
+	q := chip8.QuirksSuperChip
+	q.JumpUsesVX = false
+
+	suite.Assert().Equal("Test", e.Title)
+	suite.Assert().Equal([]string{"Someone"}, e.Authors)
+	suite.Assert().Equal("superchip", e.Platform)
+	suite.Assert().Equal(q, e.Quirks)
+	suite.Assert().Equal(30, e.Tickrate)
+	suite.Assert().Equal(uint16(0x200), e.StartAddress)
+	suite.Assert().Equal([]color.RGBA{{0, 0, 0, 255}, {255, 128, 0, 255}}, e.Palette)
+	suite.Assert().Equal(uint8(5), e.Keys["up"])
+}
+
+func (suite *DatabaseSuite) TestKnownROM() {
+	fsys := fstest.MapFS{}
+	for name, path := range map[string]string{
+		"platforms.json":   "data/platforms.json",
+		"programs.json":    "testdata/programs.json",
+		"sha1-hashes.json": "testdata/sha1-hashes.json",
+	} {
+		b, err := ioutil.ReadFile(path)
+		suite.Require().Nil(err)
+		fsys[name] = &fstest.MapFile{Data: b}
+	}
+
+	d, err := load(fsys)
+	suite.Require().Nil(err)
+
+	e, err := d.Lookup(sha1.Sum([]byte{0x00, 0xE0, 0x12, 0x02}))
+	suite.Require().Nil(err)
+	suite.Require().NotNil(e)
+	suite.Assert().Equal("Clear Loop", e.Title)
+	suite.Assert().Equal(chip8.QuirksVIP, e.Quirks)
+	suite.Assert().Equal(15, e.Tickrate)
+
+	q := chip8.QuirksSuperChip
+	q.DisplayWait = true
+
+	e, err = d.Lookup(sha1.Sum([]byte{0x00, 0xFF, 0x12, 0x02}))
+	suite.Require().Nil(err)
+	suite.Require().NotNil(e)
+	suite.Assert().Equal("High Loop", e.Title)
+	suite.Assert().Equal(q, e.Quirks)
+	suite.Assert().Equal(40, e.Tickrate)
+}
+
+func (suite *DatabaseSuite) TestEmbeddedHashes() {
+	d, err := New()
+	suite.Require().Nil(err)
+
+	for hash, i := range d.hashes {
+		suite.Require().True(i >= 0 && i < len(d.programs), hash)
+		_, ok := d.programs[i].ROMs[hash]
+		suite.Assert().True(ok, hash)
+	}
+}
+
+func (suite *DatabaseSuite) TestEmbeddedLookup() {
+	d, err := New()
+	suite.Require().Nil(err)
+
+	if len(d.hashes) == 0 {
+		suite.T().Skip("the embedded database holds no programs; run make database")
+	}
+
+	found := 0
+	for key := range d.hashes {
+		var hash [sha1.Size]byte
+		_, err := hex.Decode(hash[:], []byte(key))
+		suite.Require().Nil(err, key)
+
+		e, err := d.Lookup(hash)
+		if err != nil {
+			// Some ROMs need a platform the emulator doesn't support.
+			suite.Assert().Contains(err.Error(), "needs an unsupported platform", key)
+			continue
+		}
+
+		suite.Require().NotNil(e, key)
+		suite.Assert().NotEmpty(e.Title, key)
+		suite.Assert().True(e.Tickrate > 0, key)
+		found++
+	}
+
+	suite.Assert().True(found > 0)
+}
+
+func (suite *DatabaseSuite) TestUnknown() {
+	e, err := suite.database.Lookup(sha1.Sum(nil))
+	suite.Assert().Nil(err)
+	suite.Assert().Nil(e)
+}
+
+func (suite *DatabaseSuite) TestPlatformQuirks() {
+	for id, q := range map[string]chip8.Quirks{
+		"originalChip8": chip8.QuirksVIP,
+		"chip48":        chip8.QuirksCHIP48,
+		"superchip":     chip8.QuirksSuperChip,
+		"xochip":        chip8.QuirksXOChip,
+	} {
+		p, ok := suite.database.platforms[id]
+		suite.Require().True(ok, id)
+		suite.Assert().Equal(q, p.Quirks.chip8(id), id)
+	}
+}
+
+func (suite *DatabaseSuite) TestOverrides() {
+	unknown := sha1.Sum(nil)
+
+	filename := filepath.Join(suite.dir, "overrides.json")
+	suite.Require().Nil(ioutil.WriteFile(filename, []byte(`{
+		"`+romKey+`": {"tickrate": 50, "keys": {"a": 7}},
+		"`+hex.EncodeToString(unknown[:])+`": {"title": "Mine", "platforms": ["xochip"]}
+	}`), 0644))
+
+	suite.Require().Nil(suite.database.LoadOverrides(filename))
+
+	e, err := suite.database.Lookup(romHash)
+	suite.Require().Nil(err)
+	suite.Assert().Equal(50, e.Tickrate)
+	suite.Assert().Equal(map[string]uint8{"up": 5, "a": 7}, e.Keys)
+
+	e, err = suite.database.Lookup(unknown)
+	suite.Require().Nil(err)
+	suite.Assert().Equal("Mine", e.Title)
+	suite.Assert().Equal(chip8.QuirksXOChip, e.Quirks)
+	suite.Assert().Equal(100, e.Tickrate)
+}
+
+func (suite *DatabaseSuite) TestMissingOverrides() {
+	suite.Assert().Nil(suite.database.LoadOverrides(filepath.Join(suite.dir, "missing.json")))
+}
+
+func (suite *DatabaseSuite) TestUnsupportedPlatform() {
+	suite.database.overrides[romKey] = rom{Platforms: []string{"megachip8"}}
+
+	_, err := suite.database.Lookup(romHash)
+	suite.Assert().EqualError(err, "Test needs an unsupported platform: megachip8")
+}
+
+func TestDatabaseSuite(t *testing.T) {
+	suite.Run(t, new(DatabaseSuite))
+}
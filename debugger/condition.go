@@ -0,0 +1,109 @@
+package debugger
+
+import (
+	"chip8/chip8"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Condition compares a register with a value, such as "V3 == 5" or
+// "I >= #300". The registers V0-VF, I, PC, SP, DT and ST can be compared
+// using ==, !=, <, <=, > and >=.
+type Condition struct {
+	Register string
+	Operator string
+	Value    int
+}
+
+var conditionPattern = regexp.MustCompile(`^\s*(\w+)\s*(==|!=|<=|>=|<|>)\s*(\S+)\s*$`)
+
+func ParseCondition(text string) (*Condition, error) {
+	m := conditionPattern.FindStringSubmatch(text)
+	if m == nil {
+		return nil, fmt.Errorf("invalid condition %q", text)
+	}
+
+	c := &Condition{Register: strings.ToUpper(m[1]), Operator: m[2]}
+
+	if _, ok := c.register(chip8.Registers{}); !ok {
+		return nil, fmt.Errorf("unknown register %s", m[1])
+	}
+
+	v, err := parseNumber(m[3])
+	if err != nil {
+		return nil, err
+	}
+
+	c.Value = v
+
+	return c, nil
+}
+
+func (c *Condition) register(r chip8.Registers) (int, bool) {
+	switch c.Register {
+	case "I":
+		return int(r.I), true
+	case "PC":
+		return int(r.PC), true
+	case "SP":
+		return int(r.SP), true
+	case "DT":
+		return int(r.DelayTimer), true
+	case "ST":
+		return int(r.SoundTimer), true
+	}
+
+	if len(c.Register) == 2 && c.Register[0] == 'V' {
+		if i := strings.IndexByte("0123456789ABCDEF", c.Register[1]); i >= 0 {
+			return int(r.V[i]), true
+		}
+	}
+
+	return 0, false
+}
+
+func (c *Condition) Eval(r chip8.Registers) bool {
+	v, _ := c.register(r)
+
+	switch c.Operator {
+	case "==":
+		return v == c.Value
+	case "!=":
+		return v != c.Value
+	case "<":
+		return v < c.Value
+	case "<=":
+		return v <= c.Value
+	case ">":
+		return v > c.Value
+	case ">=":
+		return v >= c.Value
+	}
+
+	return false
+}
+
+func (c *Condition) String() string {
+	return fmt.Sprintf("%s %s #%X", c.Register, c.Operator, c.Value)
+}
+
+// parseNumber accepts hex written as #FF, $FF or 0xFF, and decimal otherwise.
+func parseNumber(text string) (int, error) {
+	digits, base := text, 10
+
+	switch {
+	case strings.HasPrefix(text, "#"), strings.HasPrefix(text, "$"):
+		digits, base = text[1:], 16
+	case strings.HasPrefix(text, "0x"), strings.HasPrefix(text, "0X"):
+		digits, base = text[2:], 16
+	}
+
+	v, err := strconv.ParseUint(digits, base, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", text)
+	}
+
+	return int(v), nil
+}
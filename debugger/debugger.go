@@ -0,0 +1,297 @@
+// Package debugger pauses, steps and inspects a running Chip8.
+//
+// The debugger takes over running frames from the frontend. Commands from the
+// REPL are queued and executed between frames on the frontend's goroutine, so
+// the machine is never touched from two goroutines at once and the frontend
+// keeps presenting the paused frame.
+package debugger
+
+import (
+	"chip8/chip8"
+	"chip8/chip8/opcodes"
+	"chip8/disasm"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrNotInSubroutine is returned by StepOut at the top level of the program.
+var ErrNotInSubroutine = errors.New("not in a subroutine")
+
+type Breakpoint struct {
+	ID int
+	// PC is the address the breakpoint is set on, ignored if AnyPC is set.
+	PC    uint16
+	AnyPC bool
+	// Condition must also hold for the breakpoint to trigger, if not nil.
+	Condition *Condition
+}
+
+func (b Breakpoint) String() string {
+	s := fmt.Sprintf("%d: ", b.ID)
+
+	if !b.AnyPC {
+		s += fmt.Sprintf("#%03X", b.PC)
+	} else {
+		s += "*"
+	}
+
+	if b.Condition != nil {
+		s += " if " + b.Condition.String()
+	}
+
+	return s
+}
+
+// syncWriter serialises writes from Serve and RunFrame, which run on
+// different goroutines.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.w.Write(p)
+}
+
+type Debugger struct {
+	chip8 *chip8.Chip8
+	out   io.Writer
+
+	paused bool
+
+	breakpoints []Breakpoint
+	watchpoints []Watchpoint
+	watchHits   []watchHit
+	nextID      int
+
+	// until pauses the machine once it returns true, for stepping over and
+	// out of subroutines.
+	until func(r chip8.Registers) bool
+	// resumed is set when execution continues, so that a breakpoint on the
+	// current instruction does not trigger again straight away.
+	resumed bool
+
+	requests chan request
+	last     string
+}
+
+// New creates a debugger for c that writes its output to out. The machine
+// starts paused.
+func New(c *chip8.Chip8, out io.Writer) *Debugger {
+	return &Debugger{
+		chip8:    c,
+		out:      &syncWriter{w: out},
+		paused:   true,
+		nextID:   1,
+		requests: make(chan request),
+	}
+}
+
+func (d *Debugger) Paused() bool {
+	return d.paused
+}
+
+func (d *Debugger) Pause() {
+	d.paused = true
+	d.until = nil
+}
+
+func (d *Debugger) Continue() {
+	d.paused = false
+	d.resumed = true
+	d.until = nil
+}
+
+// Step executes n instructions, stopping early at a breakpoint.
+func (d *Debugger) Step(n int) error {
+	d.Pause()
+
+	for i := 0; i < n && !d.chip8.Halted(); i++ {
+		if i > 0 {
+			if b, ok := d.breakpoint(); ok {
+				d.hit(b)
+				return nil
+			}
+		}
+
+		if err := d.chip8.Cycle(); err != nil {
+			return err
+		}
+
+		if d.checkWatchpoints() {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// StepOver steps a single instruction, running a subroutine call to
+// completion before pausing again.
+func (d *Debugger) StepOver() error {
+	r := d.chip8.Registers()
+
+	if op, _ := d.instruction(r.PC); op.Instruction() != opcodes.Instruction2NNN {
+		return d.Step(1)
+	}
+
+	d.Continue()
+	d.until = func(next chip8.Registers) bool {
+		return next.SP <= r.SP
+	}
+
+	return nil
+}
+
+// StepOut runs until the current subroutine returns.
+func (d *Debugger) StepOut() error {
+	r := d.chip8.Registers()
+
+	if r.SP == 0 {
+		return ErrNotInSubroutine
+	}
+
+	d.Continue()
+	d.until = func(next chip8.Registers) bool {
+		return next.SP < r.SP
+	}
+
+	return nil
+}
+
+// AddBreakpoint sets a breakpoint on pc and returns its ID. If condition is
+// not nil the breakpoint only triggers when it holds.
+func (d *Debugger) AddBreakpoint(pc uint16, condition *Condition) int {
+	return d.add(Breakpoint{PC: pc, Condition: condition})
+}
+
+// AddConditionalBreakpoint sets a breakpoint that triggers on any instruction
+// when condition holds, and returns its ID.
+func (d *Debugger) AddConditionalBreakpoint(condition *Condition) int {
+	return d.add(Breakpoint{AnyPC: true, Condition: condition})
+}
+
+func (d *Debugger) add(b Breakpoint) int {
+	b.ID = d.nextID
+	d.nextID++
+
+	d.breakpoints = append(d.breakpoints, b)
+
+	return b.ID
+}
+
+func (d *Debugger) RemoveBreakpoint(id int) error {
+	for i, b := range d.breakpoints {
+		if b.ID == id {
+			d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no breakpoint %d", id)
+}
+
+// Breakpoints returns the breakpoints in the order they were set.
+func (d *Debugger) Breakpoints() []Breakpoint {
+	return append([]Breakpoint(nil), d.breakpoints...)
+}
+
+func (d *Debugger) breakpoint() (Breakpoint, bool) {
+	r := d.chip8.Registers()
+
+	for _, b := range d.breakpoints {
+		if !b.AnyPC && b.PC != r.PC {
+			continue
+		}
+
+		if b.Condition == nil || b.Condition.Eval(r) {
+			return b, true
+		}
+	}
+
+	return Breakpoint{}, false
+}
+
+func (d *Debugger) hit(b Breakpoint) {
+	d.Pause()
+
+	fmt.Fprintf(d.out, "breakpoint %s\n", b)
+}
+
+// RunFrame runs a frame like Chip8.RunFrame unless the machine is paused, and
+// executes any commands queued by Serve. Breakpoints are checked before each
+// instruction.
+func (d *Debugger) RunFrame(cyclesPerFrame int) error {
+	d.serveRequests()
+
+	if d.paused {
+		return nil
+	}
+
+	for i := 0; i < cyclesPerFrame && !d.chip8.Halted(); i++ {
+		if !d.resumed {
+			if b, ok := d.breakpoint(); ok {
+				d.hit(b)
+				break
+			}
+		}
+
+		d.resumed = false
+
+		if err := d.chip8.Cycle(); err != nil {
+			d.Pause()
+
+			var fault chip8.Fault
+			if !errors.As(err, &fault) {
+				return err
+			}
+
+			fmt.Fprintf(d.out, "fault: %v\n", fault)
+			break
+		}
+
+		if d.checkWatchpoints() {
+			break
+		}
+
+		if d.until != nil && d.until(d.chip8.Registers()) {
+			d.Pause()
+			break
+		}
+	}
+
+	if d.paused {
+		d.printLocation()
+	}
+
+	d.chip8.TickTimers()
+
+	return nil
+}
+
+// instruction decodes the instruction at addr, along with the word that
+// follows it for F000 NNNN.
+func (d *Debugger) instruction(addr uint16) (opcodes.Opcode, uint16) {
+	var b [4]byte
+	d.chip8.ReadMemory(addr, b[:])
+
+	return opcodes.Opcode(binary.BigEndian.Uint16(b[:2])), binary.BigEndian.Uint16(b[2:])
+}
+
+func (d *Debugger) printLocation() {
+	pc := d.chip8.Registers().PC
+	op, long := d.instruction(pc)
+
+	text, ok := disasm.Format(op, long, disasm.SyntaxCowgod)
+	if !ok {
+		text = "???"
+	}
+
+	fmt.Fprintf(d.out, "#%03X  %04X  %s\n", pc, uint16(op), text)
+}
@@ -0,0 +1,272 @@
+package debugger_test
+
+import (
+	"bytes"
+	"chip8/chip8"
+	"chip8/debugger"
+	"chip8/headless"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+var program = []byte{
+	0x60, 0x00, // 200: LD V0, 0
+	0x22, 0x08, // 202: CALL 208
+	0x70, 0x01, // 204: ADD V0, 1
+	0x12, 0x02, // 206: JP 202
+	0x61, 0x05, // 208: LD V1, 5
+	0x62, 0x06, // 20A: LD V2, 6
+	0x00, 0xEE, // 20C: RET
+}
+
+type DebuggerSuite struct {
+	suite.Suite
+
+	chip8    *chip8.Chip8
+	out      bytes.Buffer
+	debugger *debugger.Debugger
+}
+
+func (suite *DebuggerSuite) SetupTest() {
+	r, err := headless.New(bytes.NewReader(program), headless.Options{Quirks: &chip8.QuirksVIP, CyclesPerFrame: 8})
+	suite.Require().Nil(err)
+
+	suite.chip8 = r.Chip8
+	suite.out.Reset()
+	suite.debugger = debugger.New(r.Chip8, &suite.out)
+}
+
+func (suite *DebuggerSuite) pc() uint16 {
+	return suite.chip8.Registers().PC
+}
+
+func (suite *DebuggerSuite) TestStartsPaused() {
+	suite.Require().Nil(suite.debugger.RunFrame(8))
+
+	suite.Assert().True(suite.debugger.Paused())
+	suite.Assert().Equal(uint16(0x200), suite.pc())
+}
+
+func (suite *DebuggerSuite) TestStep() {
+	suite.Require().Nil(suite.debugger.Step(2))
+
+	suite.Assert().Equal(uint16(0x208), suite.pc())
+	suite.Assert().Equal(uint16(1), suite.chip8.Registers().SP)
+}
+
+func (suite *DebuggerSuite) TestBreakpoint() {
+	suite.debugger.AddBreakpoint(0x20A, nil)
+	suite.debugger.Continue()
+
+	suite.Require().Nil(suite.debugger.RunFrame(8))
+
+	suite.Assert().True(suite.debugger.Paused())
+	suite.Assert().Equal(uint16(0x20A), suite.pc())
+	suite.Assert().Contains(suite.out.String(), "breakpoint 1: #20A")
+
+	// Continuing from a breakpoint does not trigger it again straight away.
+	suite.debugger.Continue()
+	suite.Require().Nil(suite.debugger.RunFrame(1))
+
+	suite.Assert().Equal(uint16(0x20C), suite.pc())
+}
+
+func (suite *DebuggerSuite) TestConditionalBreakpoint() {
+	c, err := debugger.ParseCondition("V0 == 3")
+	suite.Require().Nil(err)
+
+	suite.debugger.AddBreakpoint(0x206, c)
+	suite.debugger.Continue()
+
+	for i := 0; i < 10 && !suite.debugger.Paused(); i++ {
+		suite.Require().Nil(suite.debugger.RunFrame(8))
+	}
+
+	suite.Assert().Equal(uint16(0x206), suite.pc())
+	suite.Assert().Equal(uint8(3), suite.chip8.Registers().V[0])
+}
+
+func (suite *DebuggerSuite) TestConditionOnly() {
+	c, err := debugger.ParseCondition("v1 != 0")
+	suite.Require().Nil(err)
+
+	suite.debugger.AddConditionalBreakpoint(c)
+	suite.debugger.Continue()
+	suite.Require().Nil(suite.debugger.RunFrame(8))
+
+	suite.Assert().Equal(uint16(0x20A), suite.pc())
+}
+
+func (suite *DebuggerSuite) TestRemoveBreakpoint() {
+	id := suite.debugger.AddBreakpoint(0x208, nil)
+	suite.Require().Nil(suite.debugger.RemoveBreakpoint(id))
+	suite.Assert().Error(suite.debugger.RemoveBreakpoint(id))
+
+	suite.debugger.Continue()
+	suite.Require().Nil(suite.debugger.RunFrame(8))
+
+	suite.Assert().False(suite.debugger.Paused())
+}
+
+func (suite *DebuggerSuite) TestStepOver() {
+	suite.Require().Nil(suite.debugger.Step(1))
+	suite.Require().Nil(suite.debugger.StepOver())
+
+	suite.Require().Nil(suite.debugger.RunFrame(8))
+
+	suite.Assert().True(suite.debugger.Paused())
+	suite.Assert().Equal(uint16(0x204), suite.pc())
+	suite.Assert().Equal(uint8(5), suite.chip8.Registers().V[1])
+}
+
+func (suite *DebuggerSuite) TestStepOut() {
+	suite.Assert().Equal(debugger.ErrNotInSubroutine, suite.debugger.StepOut())
+
+	suite.Require().Nil(suite.debugger.Step(3))
+	suite.Require().Nil(suite.debugger.StepOut())
+	suite.Require().Nil(suite.debugger.RunFrame(8))
+
+	suite.Assert().True(suite.debugger.Paused())
+	suite.Assert().Equal(uint16(0x204), suite.pc())
+	suite.Assert().Equal(uint16(0), suite.chip8.Registers().SP)
+}
+
+func (suite *DebuggerSuite) TestCommands() {
+	suite.Require().Nil(suite.debugger.Execute("step 2"))
+	suite.Require().Nil(suite.debugger.Execute("regs"))
+	suite.Require().Nil(suite.debugger.Execute("stack"))
+	suite.Require().Nil(suite.debugger.Execute("x #200 4"))
+
+	out := suite.out.String()
+	suite.Assert().Contains(out, "#208  6105  LD V1, #05")
+	suite.Assert().Contains(out, "PC=#208 I=#000 SP=1")
+	suite.Assert().Contains(out, " 0: #204")
+	suite.Assert().Contains(out, "#200  60 00 22 08")
+
+	suite.Assert().Error(suite.debugger.Execute("bogus"))
+	suite.Assert().Error(suite.debugger.Execute("break if Q1 == 2"))
+}
+
+func (suite *DebuggerSuite) TestRepeatLastCommand() {
+	suite.Require().Nil(suite.debugger.Execute("s"))
+	suite.Require().Nil(suite.debugger.Execute(""))
+
+	suite.Assert().Equal(uint16(0x208), suite.pc())
+}
+
+func (suite *DebuggerSuite) TestServe() {
+	done := make(chan error)
+	go func() {
+		done <- suite.debugger.Serve(strings.NewReader("break #20C\ncontinue\n"))
+	}()
+
+	for {
+		select {
+		case err := <-done:
+			suite.Require().Nil(err)
+
+			suite.Require().Nil(suite.debugger.RunFrame(8))
+			suite.Assert().Equal(uint16(0x20C), suite.pc())
+
+			return
+		default:
+			suite.Require().Nil(suite.debugger.RunFrame(8))
+		}
+	}
+}
+
+func (suite *DebuggerSuite) TestFault() {
+	r, err := headless.New(bytes.NewReader([]byte{0x00, 0xEE}), headless.Options{})
+	suite.Require().Nil(err)
+
+	d := debugger.New(r.Chip8, &suite.out)
+	d.Continue()
+
+	suite.Require().Nil(d.RunFrame(8))
+	suite.Assert().True(d.Paused())
+	suite.Assert().Contains(suite.out.String(), "fault: stack underflow at 0x200: 00EE\n#200  00EE  RET\n")
+}
+
+func TestDebuggerSuite(t *testing.T) {
+	suite.Run(t, new(DebuggerSuite))
+}
+
+type WatchpointSuite struct {
+	suite.Suite
+
+	chip8    *chip8.Chip8
+	out      bytes.Buffer
+	debugger *debugger.Debugger
+}
+
+func (suite *WatchpointSuite) SetupTest() {
+	program := []byte{
+		0x60, 0x7B, // 200: LD V0, #7B
+		0xA3, 0x00, // 202: LD I, #300
+		0xF0, 0x33, // 204: LD B, V0
+		0xF0, 0x65, // 206: LD V0, [I]
+		0x12, 0x08, // 208: JP 208
+	}
+
+	r, err := headless.New(bytes.NewReader(program), headless.Options{CyclesPerFrame: 8})
+	suite.Require().Nil(err)
+
+	suite.chip8 = r.Chip8
+	suite.out.Reset()
+	suite.debugger = debugger.New(r.Chip8, &suite.out)
+}
+
+func (suite *WatchpointSuite) TestPauseOnWrite() {
+	suite.debugger.AddWatchpoint(0x300, 0x301, chip8.AccessWrite, nil)
+	suite.debugger.Continue()
+	suite.Require().Nil(suite.debugger.RunFrame(8))
+
+	suite.Assert().True(suite.debugger.Paused())
+	suite.Assert().Equal(uint16(0x206), suite.chip8.Registers().PC)
+	suite.Assert().Contains(suite.out.String(), "watchpoint 1: w #300 by #204 (F033): #00 -> #01")
+	suite.Assert().Contains(suite.out.String(), "watchpoint 1: w #301 by #204 (F033): #00 -> #02")
+}
+
+func (suite *WatchpointSuite) TestCallback() {
+	var accesses []chip8.MemoryAccess
+	suite.debugger.AddWatchpoint(0x300, 0x300, chip8.AccessRead|chip8.AccessWrite, func(a chip8.MemoryAccess) {
+		accesses = append(accesses, a)
+	})
+
+	suite.debugger.Continue()
+	suite.Require().Nil(suite.debugger.RunFrame(8))
+
+	suite.Assert().False(suite.debugger.Paused())
+	suite.Require().Len(accesses, 2)
+	suite.Assert().Equal(chip8.MemoryAccess{Access: chip8.AccessWrite, PC: 0x204, Opcode: 0xF033, Address: 0x300, Old: 0, New: 1}, accesses[0])
+	suite.Assert().Equal(chip8.MemoryAccess{Access: chip8.AccessRead, PC: 0x206, Opcode: 0xF065, Address: 0x300, Old: 1, New: 1}, accesses[1])
+}
+
+func (suite *WatchpointSuite) TestExecute() {
+	suite.Require().Nil(suite.debugger.Execute("watch x #208"))
+	suite.Require().Nil(suite.debugger.Execute("continue"))
+	suite.Require().Nil(suite.debugger.RunFrame(8))
+
+	suite.Assert().True(suite.debugger.Paused())
+	suite.Assert().Equal(uint16(0x208), suite.chip8.Registers().PC)
+}
+
+func (suite *WatchpointSuite) TestCommands() {
+	suite.Require().Nil(suite.debugger.Execute("trace rw #300 #302"))
+	suite.Require().Nil(suite.debugger.Execute("bl"))
+	suite.Require().Nil(suite.debugger.Execute("step 3"))
+
+	out := suite.out.String()
+	suite.Assert().Contains(out, "1: rw #300-#302 (callback)")
+	suite.Assert().Contains(out, "w #300 by #204 (F033): #00 -> #01")
+
+	suite.Require().Nil(suite.debugger.Execute("delete 1"))
+	suite.Assert().Empty(suite.debugger.Watchpoints())
+	suite.Assert().Error(suite.debugger.Execute("watch #302 #300"))
+}
+
+func TestWatchpointSuite(t *testing.T) {
+	suite.Run(t, new(WatchpointSuite))
+}
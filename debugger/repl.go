@@ -0,0 +1,304 @@
+package debugger
+
+import (
+	"bufio"
+	"chip8/chip8"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const prompt = "(chip8) "
+
+const help = `Commands:
+  c, continue            resume execution
+  p, pause               pause execution
+  s, step [N]            execute N instructions (default 1)
+  n, next                step over subroutine calls
+  finish                 run until the current subroutine returns
+  b, break ADDR [if C]   break at ADDR, optionally only when C holds
+  b, break if C          break on any instruction when C holds
+  watch [rwx] ADDR [END] pause after an access to ADDR-END (default w)
+  trace [rwx] ADDR [END] print accesses to ADDR-END without pausing
+  d, delete ID           delete a breakpoint or watchpoint
+  bl, breakpoints        list breakpoints and watchpoints
+  r, regs                show registers and timers
+  stack                  show the call stack
+  x ADDR [N]             dump N bytes of memory (default 16)
+  w, where               show the current instruction
+
+Conditions compare a register (V0-VF, I, PC, SP, DT, ST) with a value using
+==, !=, <, <=, > or >=, e.g. "V3 == 5". Numbers are decimal, or hex when
+written as #FF, $FF or 0xFF. An empty line repeats the last command.
+`
+
+type request struct {
+	line string
+	done chan struct{}
+}
+
+// Serve reads commands from in until it is closed. Each command is executed
+// by the next call to RunFrame.
+func (d *Debugger) Serve(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+
+	fmt.Fprint(d.out, prompt)
+
+	for scanner.Scan() {
+		r := request{line: scanner.Text(), done: make(chan struct{})}
+
+		d.requests <- r
+		<-r.done
+
+		fmt.Fprint(d.out, prompt)
+	}
+
+	return scanner.Err()
+}
+
+func (d *Debugger) serveRequests() {
+	for {
+		select {
+		case r := <-d.requests:
+			if err := d.Execute(r.line); err != nil {
+				fmt.Fprintf(d.out, "error: %v\n", err)
+			}
+
+			close(r.done)
+		default:
+			return
+		}
+	}
+}
+
+// Execute runs a single REPL command. An empty line repeats the last one.
+func (d *Debugger) Execute(line string) error {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		line = d.last
+	}
+
+	d.last = line
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	command, args := fields[0], fields[1:]
+
+	switch command {
+	case "h", "help":
+		fmt.Fprint(d.out, help)
+	case "c", "continue":
+		d.Continue()
+	case "p", "pause":
+		d.Pause()
+		d.printLocation()
+	case "s", "step":
+		n := 1
+		if len(args) > 0 {
+			v, err := strconv.Atoi(args[0])
+			if err != nil || v < 1 {
+				return fmt.Errorf("invalid step count %q", args[0])
+			}
+
+			n = v
+		}
+
+		if err := d.Step(n); err != nil {
+			return err
+		}
+
+		if d.paused {
+			d.printLocation()
+		}
+	case "n", "next":
+		if err := d.StepOver(); err != nil {
+			return err
+		}
+
+		if d.paused {
+			d.printLocation()
+		}
+	case "finish":
+		return d.StepOut()
+	case "b", "break":
+		return d.executeBreak(args)
+	case "d", "delete":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: delete ID")
+		}
+
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid breakpoint %q", args[0])
+		}
+
+		if d.RemoveBreakpoint(id) != nil && d.RemoveWatchpoint(id) != nil {
+			return fmt.Errorf("no breakpoint or watchpoint %d", id)
+		}
+	case "bl", "breakpoints":
+		for _, b := range d.Breakpoints() {
+			fmt.Fprintln(d.out, b)
+		}
+
+		for _, w := range d.Watchpoints() {
+			fmt.Fprintln(d.out, w)
+		}
+	case "watch", "trace":
+		return d.executeWatch(command == "trace", args)
+	case "r", "regs":
+		d.printRegisters()
+	case "stack":
+		r := d.chip8.Registers()
+		for i := int(r.SP) - 1; i >= 0; i-- {
+			fmt.Fprintf(d.out, "%2d: #%03X\n", i, r.Stack[i])
+		}
+	case "x":
+		return d.executeDump(args)
+	case "w", "where":
+		d.printLocation()
+	default:
+		return fmt.Errorf("unknown command %q, try help", command)
+	}
+
+	return nil
+}
+
+func (d *Debugger) executeBreak(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: break ADDR [if CONDITION] or break if CONDITION")
+	}
+
+	if args[0] == "if" {
+		c, err := ParseCondition(strings.Join(args[1:], " "))
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(d.out, "breakpoint %d\n", d.AddConditionalBreakpoint(c))
+
+		return nil
+	}
+
+	addr, err := parseNumber(args[0])
+	if err != nil {
+		return err
+	}
+
+	var c *Condition
+	if len(args) > 1 {
+		if args[1] != "if" {
+			return fmt.Errorf("expected if, found %q", args[1])
+		}
+
+		if c, err = ParseCondition(strings.Join(args[2:], " ")); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(d.out, "breakpoint %d\n", d.AddBreakpoint(uint16(addr), c))
+
+	return nil
+}
+
+var accessKinds = map[rune]chip8.Access{
+	'r': chip8.AccessRead,
+	'w': chip8.AccessWrite,
+	'x': chip8.AccessExecute,
+}
+
+func (d *Debugger) executeWatch(trace bool, args []string) error {
+	access := chip8.AccessWrite
+
+	if len(args) > 0 && strings.Trim(args[0], "rwx") == "" {
+		access = 0
+		for _, c := range args[0] {
+			access |= accessKinds[c]
+		}
+
+		args = args[1:]
+	}
+
+	if len(args) == 0 || len(args) > 2 {
+		return fmt.Errorf("usage: watch [rwx] ADDR [END]")
+	}
+
+	start, err := parseNumber(args[0])
+	if err != nil {
+		return err
+	}
+
+	end := start
+	if len(args) == 2 {
+		if end, err = parseNumber(args[1]); err != nil {
+			return err
+		}
+	}
+
+	if end < start {
+		return fmt.Errorf("end #%03X is before start #%03X", end, start)
+	}
+
+	var callback func(chip8.MemoryAccess)
+	if trace {
+		callback = func(a chip8.MemoryAccess) {
+			fmt.Fprintln(d.out, formatAccess(a))
+		}
+	}
+
+	fmt.Fprintf(d.out, "watchpoint %d\n", d.AddWatchpoint(uint16(start), uint16(end), access, callback))
+
+	return nil
+}
+
+const bytesPerRow = 16
+
+func (d *Debugger) executeDump(args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return fmt.Errorf("usage: x ADDR [N]")
+	}
+
+	addr, err := parseNumber(args[0])
+	if err != nil {
+		return err
+	}
+
+	n := bytesPerRow
+	if len(args) == 2 {
+		if n, err = parseNumber(args[1]); err != nil {
+			return err
+		}
+	}
+
+	b := make([]byte, n)
+	b = b[:d.chip8.ReadMemory(uint16(addr), b)]
+
+	for i := 0; i < len(b); i += bytesPerRow {
+		end := i + bytesPerRow
+		if end > len(b) {
+			end = len(b)
+		}
+
+		fmt.Fprintf(d.out, "#%03X  % X\n", addr+i, b[i:end])
+	}
+
+	return nil
+}
+
+func (d *Debugger) printRegisters() {
+	r := d.chip8.Registers()
+
+	for i, v := range r.V {
+		sep := " "
+		if i%8 == 7 {
+			sep = "\n"
+		}
+
+		fmt.Fprintf(d.out, "V%X=%02X%s", i, v, sep)
+	}
+
+	fmt.Fprintf(d.out, "PC=#%03X I=#%03X SP=%d DT=%02X ST=%02X\n", r.PC, r.I, r.SP, r.DelayTimer, r.SoundTimer)
+}
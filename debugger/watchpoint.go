@@ -0,0 +1,111 @@
+package debugger
+
+import (
+	"chip8/chip8"
+	"fmt"
+)
+
+// Watchpoint triggers on accesses to the addresses Start to End inclusive.
+type Watchpoint struct {
+	ID     int
+	Start  uint16
+	End    uint16
+	Access chip8.Access
+	// Callback is called for every matching access. If nil, the machine
+	// pauses once the accessing instruction has finished instead.
+	Callback func(chip8.MemoryAccess)
+}
+
+func (w Watchpoint) String() string {
+	s := fmt.Sprintf("%d: %s #%03X", w.ID, w.Access, w.Start)
+
+	if w.End != w.Start {
+		s += fmt.Sprintf("-#%03X", w.End)
+	}
+
+	if w.Callback != nil {
+		s += " (callback)"
+	}
+
+	return s
+}
+
+type watchHit struct {
+	watchpoint Watchpoint
+	access     chip8.MemoryAccess
+}
+
+// AddWatchpoint watches accesses of the given kinds to the addresses start to
+// end inclusive, and returns its ID. Breakpoints and watchpoints share IDs.
+func (d *Debugger) AddWatchpoint(start, end uint16, access chip8.Access, callback func(chip8.MemoryAccess)) int {
+	w := Watchpoint{ID: d.nextID, Start: start, End: end, Access: access, Callback: callback}
+	d.nextID++
+
+	d.watchpoints = append(d.watchpoints, w)
+	d.chip8.SetMemoryHook(d.onAccess)
+
+	return w.ID
+}
+
+func (d *Debugger) RemoveWatchpoint(id int) error {
+	for i, w := range d.watchpoints {
+		if w.ID == id {
+			d.watchpoints = append(d.watchpoints[:i], d.watchpoints[i+1:]...)
+
+			if len(d.watchpoints) == 0 {
+				d.chip8.SetMemoryHook(nil)
+			}
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no watchpoint %d", id)
+}
+
+// Watchpoints returns the watchpoints in the order they were set.
+func (d *Debugger) Watchpoints() []Watchpoint {
+	return append([]Watchpoint(nil), d.watchpoints...)
+}
+
+func (d *Debugger) onAccess(a chip8.MemoryAccess) {
+	for _, w := range d.watchpoints {
+		if a.Access&w.Access == 0 || a.Address < w.Start || a.Address > w.End {
+			continue
+		}
+
+		if w.Callback != nil {
+			w.Callback(a)
+		} else {
+			d.watchHits = append(d.watchHits, watchHit{watchpoint: w, access: a})
+		}
+	}
+}
+
+// checkWatchpoints pauses the machine if a watchpoint was hit by the last
+// instruction, and reports whether it did.
+func (d *Debugger) checkWatchpoints() bool {
+	if len(d.watchHits) == 0 {
+		return false
+	}
+
+	d.Pause()
+
+	for _, hit := range d.watchHits {
+		fmt.Fprintf(d.out, "watchpoint %d: %s\n", hit.watchpoint.ID, formatAccess(hit.access))
+	}
+
+	d.watchHits = d.watchHits[:0]
+
+	return true
+}
+
+func formatAccess(a chip8.MemoryAccess) string {
+	s := fmt.Sprintf("%s #%03X by #%03X (%04X): #%02X", a.Access, a.Address, a.PC, uint16(a.Opcode), a.Old)
+
+	if a.Access == chip8.AccessWrite {
+		s += fmt.Sprintf(" -> #%02X", a.New)
+	}
+
+	return s
+}
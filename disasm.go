@@ -0,0 +1,49 @@
+package main
+
+import (
+	"chip8/disasm"
+	"chip8/romfile"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+func runDisasm(args []string) error {
+	flags := flag.NewFlagSet("disasm", flag.ExitOnError)
+	syntax := flags.String("syntax", "octo", "output syntax (octo, cowgod)")
+	origin := flags.String("origin", "0x200", "address the ROM is loaded at")
+	xochip := flags.Bool("xochip", false, "decode XO-CHIP instructions")
+	addresses := flags.Bool("addresses", false, "prefix each line with its address and bytes")
+
+	flags.Usage = func() {
+		fmt.Printf("Usage: %s disasm [OPTIONS] FILENAME\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	filename := flags.Arg(0)
+	if filename == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	s, err := disasm.ParseSyntax(*syntax)
+	if err != nil {
+		return err
+	}
+
+	o, err := strconv.ParseUint(*origin, 0, 16)
+	if err != nil {
+		return fmt.Errorf("invalid origin %q: %v", *origin, err)
+	}
+
+	rom, err := romfile.Load(filename)
+	if err != nil {
+		return err
+	}
+
+	listing := disasm.Disassemble(rom, disasm.Options{Syntax: s, Origin: uint16(o), XOChip: *xochip})
+
+	return listing.Fprint(os.Stdout, *addresses)
+}
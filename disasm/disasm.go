@@ -0,0 +1,239 @@
+// Package disasm turns CHIP-8 programs back into assembly listings.
+package disasm
+
+import (
+	"chip8/chip8/opcodes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type Syntax int
+
+const (
+	// SyntaxOcto produces Octo source.
+	SyntaxOcto Syntax = iota
+	// SyntaxCowgod produces the classic mnemonics from Cowgod's technical
+	// reference, as accepted by the asm package.
+	SyntaxCowgod
+)
+
+var syntaxes = map[string]Syntax{
+	"octo":   SyntaxOcto,
+	"cowgod": SyntaxCowgod,
+}
+
+func ParseSyntax(name string) (Syntax, error) {
+	s, ok := syntaxes[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown syntax %q, expected octo or cowgod", name)
+	}
+
+	return s, nil
+}
+
+type Options struct {
+	Syntax Syntax
+	// Origin is the address the ROM is loaded at, normally 0x200.
+	Origin uint16
+	// XOChip decodes F000 NNNN as a four byte instruction.
+	XOChip bool
+}
+
+// Line is a single instruction or a run of data bytes.
+type Line struct {
+	Address uint16
+	Bytes   []byte
+	Label   string
+	Text    string
+	Code    bool
+}
+
+type Listing struct {
+	Lines  []Line
+	Syntax Syntax
+	Origin uint16
+}
+
+const dataBytesPerLine = 8
+
+// Disassemble separates code from data by following every jump, call and
+// skip reachable from the origin, and returns a listing with labels for the
+// targets of jumps, calls and index loads that fall within the ROM.
+func Disassemble(rom []byte, options Options) *Listing {
+	origin := int(options.Origin)
+	end := origin + len(rom)
+
+	word := func(addr int) uint16 {
+		return binary.BigEndian.Uint16(rom[addr-origin:])
+	}
+
+	size := func(addr int) int {
+		if options.XOChip && word(addr) == 0xF000 {
+			return 4
+		}
+
+		return 2
+	}
+
+	code := make(map[int]bool)
+	claimed := make([]bool, len(rom))
+	targets := make(map[int]bool)
+
+	pending := []int{origin}
+	for len(pending) > 0 {
+		addr := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		for addr >= origin && addr+2 <= end && !code[addr] {
+			op := opcodes.Opcode(word(addr))
+			n := size(addr)
+
+			if !known(op, options.XOChip) || addr+n > end || anyClaimed(claimed[addr-origin:addr-origin+n]) {
+				break
+			}
+
+			code[addr] = true
+			for i := 0; i < n; i++ {
+				claimed[addr-origin+i] = true
+			}
+
+			next := addr + n
+
+			switch op.Instruction() {
+			case opcodes.Instruction1NNN:
+				targets[int(op.NNN())] = true
+				pending = append(pending, int(op.NNN()))
+				next = -1
+			case opcodes.Instruction2NNN:
+				targets[int(op.NNN())] = true
+				pending = append(pending, int(op.NNN()))
+			case opcodes.InstructionANNN:
+				targets[int(op.NNN())] = true
+			case opcodes.InstructionF000:
+				targets[int(word(addr+2))] = true
+			case opcodes.Instruction00EE, opcodes.Instruction00FD, opcodes.InstructionBNNN:
+				next = -1
+			case opcodes.Instruction3XNN, opcodes.Instruction4XNN, opcodes.Instruction5XY0, opcodes.Instruction9XY0,
+				opcodes.InstructionEX9E, opcodes.InstructionEXA1:
+				if next+2 <= end {
+					pending = append(pending, next+size(next))
+				}
+			}
+
+			if next < 0 {
+				break
+			}
+
+			addr = next
+		}
+	}
+
+	var lines []Line
+	for addr := origin; addr < end; {
+		if code[addr] {
+			n := size(addr)
+			lines = append(lines, Line{Address: uint16(addr), Bytes: rom[addr-origin : addr-origin+n], Code: true})
+			addr += n
+
+			continue
+		}
+
+		start := addr
+		for addr < end && !code[addr] && addr-start < dataBytesPerLine && (addr == start || !targets[addr]) {
+			addr++
+		}
+
+		lines = append(lines, Line{Address: uint16(start), Bytes: rom[start-origin : addr-origin]})
+	}
+
+	labels := make(map[uint16]string)
+	for i := range lines {
+		if targets[int(lines[i].Address)] {
+			labels[lines[i].Address] = fmt.Sprintf("L%03X", lines[i].Address)
+			lines[i].Label = labels[lines[i].Address]
+		}
+	}
+
+	for i := range lines {
+		if lines[i].Code {
+			lines[i].Text = format(lines[i].Bytes, options.Syntax, labels)
+		} else {
+			lines[i].Text = formatData(lines[i].Bytes, options.Syntax)
+		}
+	}
+
+	return &Listing{Lines: lines, Syntax: options.Syntax, Origin: options.Origin}
+}
+
+func anyClaimed(claimed []bool) bool {
+	for _, c := range claimed {
+		if c {
+			return true
+		}
+	}
+
+	return false
+}
+
+func known(op opcodes.Opcode, xochip bool) bool {
+	switch op.Instruction() {
+	case opcodes.InstructionUnknown:
+		return false
+	case opcodes.Instruction00DN, opcodes.Instruction5XY2, opcodes.Instruction5XY3, opcodes.InstructionF000,
+		opcodes.InstructionFN01, opcodes.InstructionF002, opcodes.InstructionFX3A:
+		return xochip
+	}
+
+	return true
+}
+
+// Format returns the text of a single instruction, or false if op is not a
+// valid instruction. long is the word following an F000 instruction.
+func Format(op opcodes.Opcode, long uint16, syntax Syntax) (string, bool) {
+	if op.Instruction() == opcodes.InstructionUnknown {
+		return "", false
+	}
+
+	b := []byte{byte(op >> 8), byte(op), byte(long >> 8), byte(long)}
+	if op.Instruction() != opcodes.InstructionF000 {
+		b = b[:2]
+	}
+
+	return format(b, syntax, nil), true
+}
+
+// Fprint writes the listing as source that can be assembled again. If
+// addresses is set, each line is prefixed with its address and raw bytes.
+func (l *Listing) Fprint(w io.Writer, addresses bool) error {
+	var b strings.Builder
+
+	if l.Origin != 0x200 {
+		if l.Syntax == SyntaxOcto {
+			fmt.Fprintf(&b, ":org 0x%03X\n", l.Origin)
+		} else {
+			fmt.Fprintf(&b, "ORG #%03X\n", l.Origin)
+		}
+	}
+
+	for _, line := range l.Lines {
+		if line.Label != "" {
+			if l.Syntax == SyntaxOcto {
+				fmt.Fprintf(&b, ": %s\n", line.Label)
+			} else {
+				fmt.Fprintf(&b, "%s:\n", line.Label)
+			}
+		}
+
+		if addresses {
+			fmt.Fprintf(&b, "%04X  %-12X", line.Address, line.Bytes)
+		}
+
+		fmt.Fprintf(&b, "\t%s\n", line.Text)
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
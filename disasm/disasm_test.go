@@ -0,0 +1,112 @@
+package disasm_test
+
+import (
+	"chip8/chip8/opcodes"
+	"chip8/disasm"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+var program = []byte{
+	0x00, 0xE0, // 200: clear
+	0xA2, 0x0C, // 202: i := sprite
+	0x22, 0x08, // 204: call draw
+	0x12, 0x06, // 206: loop
+	0xD0, 0x05, // 208: draw
+	0x00, 0xEE, // 20A: return
+	0xF0, 0x90, 0x90, 0x90, 0xF0, // 20C: sprite
+}
+
+type DisassembleSuite struct {
+	suite.Suite
+}
+
+func (suite *DisassembleSuite) listing(syntax disasm.Syntax) string {
+	listing := disasm.Disassemble(program, disasm.Options{Syntax: syntax, Origin: 0x200})
+
+	var b strings.Builder
+	suite.Require().Nil(listing.Fprint(&b, false))
+
+	return b.String()
+}
+
+func (suite *DisassembleSuite) TestCowgod() {
+	expected := "" +
+		"\tCLS\n" +
+		"\tLD I, L20C\n" +
+		"\tCALL L208\n" +
+		"L206:\n" +
+		"\tJP L206\n" +
+		"L208:\n" +
+		"\tDRW V0, V0, 5\n" +
+		"\tRET\n" +
+		"L20C:\n" +
+		"\tDB #F0, #90, #90, #90, #F0\n"
+
+	suite.Assert().Equal(expected, suite.listing(disasm.SyntaxCowgod))
+}
+
+func (suite *DisassembleSuite) TestOcto() {
+	expected := "" +
+		"\tclear\n" +
+		"\ti := L20C\n" +
+		"\tL208\n" +
+		": L206\n" +
+		"\tjump L206\n" +
+		": L208\n" +
+		"\tsprite v0 v0 5\n" +
+		"\treturn\n" +
+		": L20C\n" +
+		"\t0xF0 0x90 0x90 0x90 0xF0\n"
+
+	suite.Assert().Equal(expected, suite.listing(disasm.SyntaxOcto))
+}
+
+func (suite *DisassembleSuite) TestCodeAndData() {
+	listing := disasm.Disassemble(program, disasm.Options{Origin: 0x200})
+
+	var code, data int
+	for _, line := range listing.Lines {
+		if line.Code {
+			code++
+		} else {
+			data++
+		}
+	}
+
+	suite.Assert().Equal(6, code)
+	suite.Assert().Equal(1, data)
+}
+
+func (suite *DisassembleSuite) TestSkipFollowsBothPaths() {
+	rom := []byte{
+		0x30, 0x00, // 200: skip
+		0x12, 0x06, // 202: jump 206
+		0x00, 0xFD, // 204: exit
+		0x00, 0xFD, // 206: exit
+	}
+
+	listing := disasm.Disassemble(rom, disasm.Options{Origin: 0x200})
+	for _, line := range listing.Lines {
+		suite.Assert().True(line.Code, "%04X", line.Address)
+	}
+}
+
+func (suite *DisassembleSuite) TestFormatEveryInstruction() {
+	for op := 0; op <= 0xFFFF; op++ {
+		opcode := opcodes.Opcode(op)
+
+		text, ok := disasm.Format(opcode, 0x1234, disasm.SyntaxCowgod)
+		suite.Assert().Equal(opcode.Instruction() != opcodes.InstructionUnknown, ok)
+
+		if ok {
+			suite.Assert().NotEmpty(text)
+		}
+	}
+}
+
+func TestDisassemble(t *testing.T) {
+	suite.Run(t, new(DisassembleSuite))
+}
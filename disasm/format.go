@@ -0,0 +1,287 @@
+package disasm
+
+import (
+	"chip8/chip8/opcodes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+func format(b []byte, syntax Syntax, labels map[uint16]string) string {
+	op := opcodes.Opcode(binary.BigEndian.Uint16(b))
+
+	var long uint16
+	if len(b) == 4 {
+		long = binary.BigEndian.Uint16(b[2:])
+	}
+
+	if syntax == SyntaxOcto {
+		return formatOcto(op, long, labels)
+	}
+
+	return formatCowgod(op, long, labels)
+}
+
+func formatData(b []byte, syntax Syntax) string {
+	values := make([]string, len(b))
+
+	for i, v := range b {
+		if syntax == SyntaxOcto {
+			values[i] = fmt.Sprintf("0x%02X", v)
+		} else {
+			values[i] = fmt.Sprintf("#%02X", v)
+		}
+	}
+
+	if syntax == SyntaxOcto {
+		return strings.Join(values, " ")
+	}
+
+	return "DB " + strings.Join(values, ", ")
+}
+
+func formatOcto(op opcodes.Opcode, long uint16, labels map[uint16]string) string {
+	x, y, n, nn := op.X(), op.Y(), op.N(), op.NN()
+
+	addr := func(a uint16) string {
+		if label, ok := labels[a]; ok {
+			return label
+		}
+
+		return fmt.Sprintf("0x%03X", a)
+	}
+
+	switch op.Instruction() {
+	case opcodes.Instruction00CN:
+		return fmt.Sprintf("scroll-down %d", n)
+	case opcodes.Instruction00DN:
+		return fmt.Sprintf("scroll-up %d", n)
+	case opcodes.Instruction00E0:
+		return "clear"
+	case opcodes.Instruction00EE:
+		return "return"
+	case opcodes.Instruction00FB:
+		return "scroll-right"
+	case opcodes.Instruction00FC:
+		return "scroll-left"
+	case opcodes.Instruction00FD:
+		return "exit"
+	case opcodes.Instruction00FE:
+		return "lores"
+	case opcodes.Instruction00FF:
+		return "hires"
+	case opcodes.Instruction1NNN:
+		return "jump " + addr(op.NNN())
+	case opcodes.Instruction2NNN:
+		if label, ok := labels[op.NNN()]; ok {
+			return label
+		}
+
+		return fmt.Sprintf(":call 0x%03X", op.NNN())
+	case opcodes.Instruction3XNN:
+		return fmt.Sprintf("if v%X != 0x%02X then", x, nn)
+	case opcodes.Instruction4XNN:
+		return fmt.Sprintf("if v%X == 0x%02X then", x, nn)
+	case opcodes.Instruction5XY0:
+		return fmt.Sprintf("if v%X != v%X then", x, y)
+	case opcodes.Instruction5XY2:
+		return fmt.Sprintf("save v%X - v%X", x, y)
+	case opcodes.Instruction5XY3:
+		return fmt.Sprintf("load v%X - v%X", x, y)
+	case opcodes.Instruction6XNN:
+		return fmt.Sprintf("v%X := 0x%02X", x, nn)
+	case opcodes.Instruction7XNN:
+		return fmt.Sprintf("v%X += 0x%02X", x, nn)
+	case opcodes.Instruction8XY0:
+		return fmt.Sprintf("v%X := v%X", x, y)
+	case opcodes.Instruction8XY1:
+		return fmt.Sprintf("v%X |= v%X", x, y)
+	case opcodes.Instruction8XY2:
+		return fmt.Sprintf("v%X &= v%X", x, y)
+	case opcodes.Instruction8XY3:
+		return fmt.Sprintf("v%X ^= v%X", x, y)
+	case opcodes.Instruction8XY4:
+		return fmt.Sprintf("v%X += v%X", x, y)
+	case opcodes.Instruction8XY5:
+		return fmt.Sprintf("v%X -= v%X", x, y)
+	case opcodes.Instruction8XY6:
+		return fmt.Sprintf("v%X >>= v%X", x, y)
+	case opcodes.Instruction8XY7:
+		return fmt.Sprintf("v%X =- v%X", x, y)
+	case opcodes.Instruction8XYE:
+		return fmt.Sprintf("v%X <<= v%X", x, y)
+	case opcodes.Instruction9XY0:
+		return fmt.Sprintf("if v%X == v%X then", x, y)
+	case opcodes.InstructionANNN:
+		return "i := " + addr(op.NNN())
+	case opcodes.InstructionBNNN:
+		return "jump0 " + addr(op.NNN())
+	case opcodes.InstructionCXNN:
+		return fmt.Sprintf("v%X := random 0x%02X", x, nn)
+	case opcodes.InstructionDXYN, opcodes.InstructionDXY0:
+		return fmt.Sprintf("sprite v%X v%X %d", x, y, n)
+	case opcodes.InstructionEX9E:
+		return fmt.Sprintf("if v%X -key then", x)
+	case opcodes.InstructionEXA1:
+		return fmt.Sprintf("if v%X key then", x)
+	case opcodes.InstructionF000:
+		if label, ok := labels[long]; ok {
+			return "i := long " + label
+		}
+
+		return fmt.Sprintf("i := long 0x%04X", long)
+	case opcodes.InstructionFN01:
+		return fmt.Sprintf("plane %d", x)
+	case opcodes.InstructionF002:
+		return "audio"
+	case opcodes.InstructionFX07:
+		return fmt.Sprintf("v%X := delay", x)
+	case opcodes.InstructionFX0A:
+		return fmt.Sprintf("v%X := key", x)
+	case opcodes.InstructionFX15:
+		return fmt.Sprintf("delay := v%X", x)
+	case opcodes.InstructionFX18:
+		return fmt.Sprintf("buzzer := v%X", x)
+	case opcodes.InstructionFX1E:
+		return fmt.Sprintf("i += v%X", x)
+	case opcodes.InstructionFX29:
+		return fmt.Sprintf("i := hex v%X", x)
+	case opcodes.InstructionFX30:
+		return fmt.Sprintf("i := bighex v%X", x)
+	case opcodes.InstructionFX33:
+		return fmt.Sprintf("bcd v%X", x)
+	case opcodes.InstructionFX3A:
+		return fmt.Sprintf("pitch := v%X", x)
+	case opcodes.InstructionFX55:
+		return fmt.Sprintf("save v%X", x)
+	case opcodes.InstructionFX65:
+		return fmt.Sprintf("load v%X", x)
+	case opcodes.InstructionFX75:
+		return fmt.Sprintf("saveflags v%X", x)
+	case opcodes.InstructionFX85:
+		return fmt.Sprintf("loadflags v%X", x)
+	}
+
+	return fmt.Sprintf("0x%02X 0x%02X", byte(op>>8), byte(op))
+}
+
+func formatCowgod(op opcodes.Opcode, long uint16, labels map[uint16]string) string {
+	x, y, n, nn := op.X(), op.Y(), op.N(), op.NN()
+
+	addr := func(a uint16) string {
+		if label, ok := labels[a]; ok {
+			return label
+		}
+
+		return fmt.Sprintf("#%03X", a)
+	}
+
+	switch op.Instruction() {
+	case opcodes.Instruction00CN:
+		return fmt.Sprintf("SCD %d", n)
+	case opcodes.Instruction00DN:
+		return fmt.Sprintf("SCU %d", n)
+	case opcodes.Instruction00E0:
+		return "CLS"
+	case opcodes.Instruction00EE:
+		return "RET"
+	case opcodes.Instruction00FB:
+		return "SCR"
+	case opcodes.Instruction00FC:
+		return "SCL"
+	case opcodes.Instruction00FD:
+		return "EXIT"
+	case opcodes.Instruction00FE:
+		return "LOW"
+	case opcodes.Instruction00FF:
+		return "HIGH"
+	case opcodes.Instruction1NNN:
+		return "JP " + addr(op.NNN())
+	case opcodes.Instruction2NNN:
+		return "CALL " + addr(op.NNN())
+	case opcodes.Instruction3XNN:
+		return fmt.Sprintf("SE V%X, #%02X", x, nn)
+	case opcodes.Instruction4XNN:
+		return fmt.Sprintf("SNE V%X, #%02X", x, nn)
+	case opcodes.Instruction5XY0:
+		return fmt.Sprintf("SE V%X, V%X", x, y)
+	case opcodes.Instruction5XY2:
+		return fmt.Sprintf("SAVE V%X, V%X", x, y)
+	case opcodes.Instruction5XY3:
+		return fmt.Sprintf("LOAD V%X, V%X", x, y)
+	case opcodes.Instruction6XNN:
+		return fmt.Sprintf("LD V%X, #%02X", x, nn)
+	case opcodes.Instruction7XNN:
+		return fmt.Sprintf("ADD V%X, #%02X", x, nn)
+	case opcodes.Instruction8XY0:
+		return fmt.Sprintf("LD V%X, V%X", x, y)
+	case opcodes.Instruction8XY1:
+		return fmt.Sprintf("OR V%X, V%X", x, y)
+	case opcodes.Instruction8XY2:
+		return fmt.Sprintf("AND V%X, V%X", x, y)
+	case opcodes.Instruction8XY3:
+		return fmt.Sprintf("XOR V%X, V%X", x, y)
+	case opcodes.Instruction8XY4:
+		return fmt.Sprintf("ADD V%X, V%X", x, y)
+	case opcodes.Instruction8XY5:
+		return fmt.Sprintf("SUB V%X, V%X", x, y)
+	case opcodes.Instruction8XY6:
+		return fmt.Sprintf("SHR V%X, V%X", x, y)
+	case opcodes.Instruction8XY7:
+		return fmt.Sprintf("SUBN V%X, V%X", x, y)
+	case opcodes.Instruction8XYE:
+		return fmt.Sprintf("SHL V%X, V%X", x, y)
+	case opcodes.Instruction9XY0:
+		return fmt.Sprintf("SNE V%X, V%X", x, y)
+	case opcodes.InstructionANNN:
+		return "LD I, " + addr(op.NNN())
+	case opcodes.InstructionBNNN:
+		return "JP V0, " + addr(op.NNN())
+	case opcodes.InstructionCXNN:
+		return fmt.Sprintf("RND V%X, #%02X", x, nn)
+	case opcodes.InstructionDXYN, opcodes.InstructionDXY0:
+		return fmt.Sprintf("DRW V%X, V%X, %d", x, y, n)
+	case opcodes.InstructionEX9E:
+		return fmt.Sprintf("SKP V%X", x)
+	case opcodes.InstructionEXA1:
+		return fmt.Sprintf("SKNP V%X", x)
+	case opcodes.InstructionF000:
+		if label, ok := labels[long]; ok {
+			return "LDL I, " + label
+		}
+
+		return fmt.Sprintf("LDL I, #%04X", long)
+	case opcodes.InstructionFN01:
+		return fmt.Sprintf("PLANE %d", x)
+	case opcodes.InstructionF002:
+		return "AUDIO"
+	case opcodes.InstructionFX07:
+		return fmt.Sprintf("LD V%X, DT", x)
+	case opcodes.InstructionFX0A:
+		return fmt.Sprintf("LD V%X, K", x)
+	case opcodes.InstructionFX15:
+		return fmt.Sprintf("LD DT, V%X", x)
+	case opcodes.InstructionFX18:
+		return fmt.Sprintf("LD ST, V%X", x)
+	case opcodes.InstructionFX1E:
+		return fmt.Sprintf("ADD I, V%X", x)
+	case opcodes.InstructionFX29:
+		return fmt.Sprintf("LD F, V%X", x)
+	case opcodes.InstructionFX30:
+		return fmt.Sprintf("LD HF, V%X", x)
+	case opcodes.InstructionFX33:
+		return fmt.Sprintf("LD B, V%X", x)
+	case opcodes.InstructionFX3A:
+		return fmt.Sprintf("PITCH V%X", x)
+	case opcodes.InstructionFX55:
+		return fmt.Sprintf("LD [I], V%X", x)
+	case opcodes.InstructionFX65:
+		return fmt.Sprintf("LD V%X, [I]", x)
+	case opcodes.InstructionFX75:
+		return fmt.Sprintf("LD R, V%X", x)
+	case opcodes.InstructionFX85:
+		return fmt.Sprintf("LD V%X, R", x)
+	}
+
+	return fmt.Sprintf("DB #%02X, #%02X", byte(op>>8), byte(op))
+}
@@ -0,0 +1,91 @@
+package emulator
+
+import (
+	sdl "github.com/veandco/go-sdl2/sdl"
+)
+
+// padPrefix starts the keymap names of controller inputs: "Pad " and the SDL
+// name of a button, or of an axis followed by - or + for its direction.
+const padPrefix = "pad "
+
+// DefaultStickThreshold is used when Options.StickThreshold is zero.
+const DefaultStickThreshold = 0.5
+
+// defaultPad binds controllers when the layout has no controller inputs. The
+// D-pad and left stick press the keys under W, A, S and D on a QWERTY
+// keyboard, and the face and shoulder buttons the keys around them.
+var defaultPad = map[string]int{
+	"pad dpup":    0x5,
+	"pad dpdown":  0x8,
+	"pad dpleft":  0x7,
+	"pad dpright": 0x9,
+
+	"pad lefty-": 0x5,
+	"pad lefty+": 0x8,
+	"pad leftx-": 0x7,
+	"pad leftx+": 0x9,
+
+	"pad a": 0x6,
+	"pad b": 0x4,
+	"pad x": 0xD,
+	"pad y": 0xE,
+
+	"pad leftshoulder":  0x1,
+	"pad rightshoulder": 0x2,
+	"pad back":          0x0,
+	"pad start":         0xF,
+}
+
+// handleControllerDevice opens controllers as they are connected, including
+// those present at startup, and closes them when they are removed.
+func (k *keys) handleControllerDevice(e *sdl.ControllerDeviceEvent) {
+	switch e.Type {
+	case sdl.CONTROLLERDEVICEADDED:
+		c := sdl.GameControllerOpen(int(e.Which))
+		if c == nil {
+			return
+		}
+
+		k.controllers[c.Joystick().InstanceID()] = c
+	case sdl.CONTROLLERDEVICEREMOVED:
+		if c, ok := k.controllers[e.Which]; ok {
+			c.Close()
+			delete(k.controllers, e.Which)
+		}
+
+		// Release anything held on the controller so no key sticks down.
+		for in := range k.held {
+			if in.pad != "" && in.controller == e.Which {
+				k.press(in, k.padMap[in.pad], false)
+			}
+		}
+	}
+}
+
+func (k *keys) handleControllerButton(e *sdl.ControllerButtonEvent) {
+	name := padPrefix + sdl.GameControllerGetStringForButton(sdl.GameControllerButton(e.Button))
+
+	if key, ok := k.padMap[name]; ok {
+		k.press(hostInput{controller: e.Which, pad: name}, key, e.Type == sdl.CONTROLLERBUTTONDOWN)
+	}
+}
+
+// handleControllerAxis treats each direction of an axis as a button that is
+// down while the axis is past the threshold.
+func (k *keys) handleControllerAxis(e *sdl.ControllerAxisEvent) {
+	axis := padPrefix + sdl.GameControllerGetStringForAxis(sdl.GameControllerAxis(e.Axis))
+
+	for _, direction := range []struct {
+		suffix  string
+		pressed bool
+	}{
+		{"-", e.Value <= -k.threshold},
+		{"+", e.Value >= k.threshold},
+	} {
+		name := axis + direction.suffix
+
+		if key, ok := k.padMap[name]; ok {
+			k.press(hostInput{controller: e.Which, pad: name}, key, direction.pressed)
+		}
+	}
+}
@@ -5,73 +5,89 @@ package emulator
 import "C"
 
 import (
+	"bytes"
+	"chip8/audio"
+	"chip8/capture"
 	"chip8/chip8"
 	"chip8/chip8/display"
+	"chip8/debugger"
+	"chip8/frontend"
+	"chip8/keymap"
+	"chip8/movie"
+	"chip8/rewind"
+	"chip8/romfile"
+	"chip8/screenshot"
+	"crypto/sha1"
+	"errors"
 	"fmt"
+	"image/color"
+	"io"
 	"math"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"time"
 	"unsafe"
 
 	sdl "github.com/veandco/go-sdl2/sdl"
 )
 
-var keyMap = map[sdl.Scancode]int{
-	sdl.SCANCODE_1: 0x1,
-	sdl.SCANCODE_2: 0x2,
-	sdl.SCANCODE_3: 0x3,
-	sdl.SCANCODE_4: 0xC,
-	sdl.SCANCODE_Q: 0x4,
-	sdl.SCANCODE_W: 0x5,
-	sdl.SCANCODE_E: 0x6,
-	sdl.SCANCODE_R: 0xD,
-	sdl.SCANCODE_A: 0x7,
-	sdl.SCANCODE_S: 0x8,
-	sdl.SCANCODE_D: 0x9,
-	sdl.SCANCODE_F: 0xE,
-	sdl.SCANCODE_Z: 0xA,
-	sdl.SCANCODE_X: 0x0,
-	sdl.SCANCODE_C: 0xB,
-	sdl.SCANCODE_V: 0xF,
+// inputKeys are the host keys bound to the inputs a ROM's settings name.
+var inputKeys = map[string][]string{
+	"up":    {"Up", "Pad dpup", "Pad lefty-"},
+	"down":  {"Down", "Pad dpdown", "Pad lefty+"},
+	"left":  {"Left", "Pad dpleft", "Pad leftx-"},
+	"right": {"Right", "Pad dpright", "Pad leftx+"},
+	"a":     {"Space", "Pad a"},
+	"b":     {"Return", "Pad b"},
 }
 
 const (
-	toneHz   = 440
-	sampleHz = 22050
-	dPhase   = 2 * math.Pi * toneHz / sampleHz
+	sampleHz = audio.DefaultSampleRate
 
-	cyclesPerSecond = 500
+	framesPerSecond = 60
+
+	rewindKey     = sdl.SCANCODE_BACKSPACE
+	screenshotKey = sdl.SCANCODE_F12
+
+	// DefaultScreenshotScale matches the size of the window.
+	DefaultScreenshotScale = 10
 )
 
+// synth generates the samples played by the audio callback.
+var synth *audio.Synth
+
 //export AudioCallback
 func AudioCallback(userdata unsafe.Pointer, stream *C.Uint8, length C.int) {
 	n := int(length)
 
-	var buf []C.Uint8
+	var buf []uint8
 	hdr := (*reflect.SliceHeader)(unsafe.Pointer(&buf))
 	hdr.Cap = n
 	hdr.Len = n
 	hdr.Data = uintptr(unsafe.Pointer(stream))
 
-	var phase float64
-	for i := 0; i < n; i += 2 {
-		phase += dPhase
-		sample := C.Uint8((math.Sin(phase) + 0.999999) * 128)
-		buf[i] = sample
-		buf[i+1] = sample
-	}
+	synth.Read(buf)
 }
 
-type beeper struct{}
+// beeper plays the synth, which is silent while the sound timer is stopped,
+// for as long as the emulator runs, and passes the sound to the recorder if
+// there is one.
+type beeper struct {
+	*audio.Synth
+	recorder *audio.Recorder
+}
+
+func newBeeper(options audio.Options, record io.Writer) (*beeper, error) {
+	synth = audio.NewSynth(sampleHz, options)
 
-func newBeeper() (*beeper, error) {
 	spec := sdl.AudioSpec{
 		Freq:     sampleHz,
 		Format:   sdl.AUDIO_U8,
-		Channels: 2,
-		Samples:  1024,
+		Channels: 1,
+		Samples:  512,
 		Callback: sdl.AudioCallback(C.AudioCallback),
 	}
 
@@ -79,46 +95,171 @@ func newBeeper() (*beeper, error) {
 		return nil, fmt.Errorf("failed to open audio: %v", err)
 	}
 
-	return &beeper{}, nil
+	sdl.PauseAudio(false)
+
+	b := &beeper{Synth: synth}
+	if record != nil {
+		b.recorder = audio.NewRecorder(record, sampleHz, options)
+	}
+
+	return b, nil
 }
 
 func (b *beeper) destroy() {
 	sdl.CloseAudio()
 }
 
-func (b *beeper) Beep() {
-	sdl.PauseAudio(false)
+func (b *beeper) SetSound(on bool) {
+	b.Synth.SetSound(on)
 
-	go func() {
-		for {
-			<-time.After(time.Second / 5)
-			sdl.PauseAudio(true)
-		}
-	}()
+	if b.recorder != nil {
+		b.recorder.SetSound(on)
+	}
+}
+
+// silence stops the tone while the machine isn't running, until its timers
+// next turn it on. The recording is left alone, as no frames pass in it.
+func (b *beeper) silence() {
+	b.Synth.SetSound(false)
+}
+
+func (b *beeper) SetPattern(pattern [16]uint8, pitch uint8) {
+	b.Synth.SetPattern(pattern, pitch)
+
+	if b.recorder != nil {
+		b.recorder.SetPattern(pattern, pitch)
+	}
+}
+
+func (b *beeper) err() error {
+	if b.recorder == nil {
+		return nil
+	}
+
+	return b.recorder.Err()
+}
+
+// hostInput identifies a held keyboard key or controller input.
+type hostInput struct {
+	keycode    sdl.Keycode
+	controller sdl.JoystickID
+	pad        string
 }
 
 type keys struct {
+	keyMap map[sdl.Keycode]int
+	padMap map[string]int
+
+	controllers map[sdl.JoystickID]*sdl.GameController
+	threshold   int16
+
+	held map[hostInput]bool
+	// down counts the host inputs held for each CHIP-8 key.
+	down [16]int
+
 	current  [16]bool
 	previous [16]bool
 }
 
+// newKeys binds the keypad to layout, using defaultPad for controllers if
+// the layout has no controller inputs, and the inputs in the ROM's settings
+// to inputKeys. Analog axes press a key once they are threshold of the way
+// from the centre.
+func newKeys(layout keymap.Layout, inputs map[string]uint8, threshold float64) (*keys, error) {
+	bindings, err := layout.Bindings()
+	if err != nil {
+		return nil, err
+	}
+
+	k := &keys{
+		keyMap: make(map[sdl.Keycode]int),
+		padMap: make(map[string]int),
+
+		controllers: make(map[sdl.JoystickID]*sdl.GameController),
+		threshold:   int16(threshold * math.MaxInt16),
+
+		held: make(map[hostInput]bool),
+	}
+
+	for name, key := range bindings {
+		if strings.HasPrefix(name, padPrefix) {
+			k.padMap[name] = int(key)
+			continue
+		}
+
+		code := sdl.GetKeyFromName(name)
+		if code == sdl.K_UNKNOWN {
+			return nil, fmt.Errorf("unknown key %q in keymap", name)
+		}
+
+		k.keyMap[code] = int(key)
+	}
+
+	if len(k.padMap) == 0 {
+		for name, key := range defaultPad {
+			k.padMap[name] = key
+		}
+	}
+
+	for name, key := range inputs {
+		if key >= 16 {
+			continue
+		}
+
+		for _, host := range inputKeys[name] {
+			host = strings.ToLower(host)
+
+			if strings.HasPrefix(host, padPrefix) {
+				k.padMap[host] = int(key)
+			} else {
+				k.keyMap[sdl.GetKeyFromName(host)] = int(key)
+			}
+		}
+	}
+
+	return k, nil
+}
+
+func (k *keys) destroy() {
+	for _, c := range k.controllers {
+		c.Close()
+	}
+}
+
+// press records a host input being pressed or released. A CHIP-8 key stays
+// down until every host input bound to it is released.
+func (k *keys) press(in hostInput, key int, pressed bool) {
+	if k.held[in] == pressed {
+		return
+	}
+
+	if pressed {
+		k.held[in] = true
+		k.down[key]++
+	} else {
+		delete(k.held, in)
+		k.down[key]--
+	}
+
+	k.current[key] = k.down[key] > 0
+}
+
 func (k *keys) startFrame() {
 	copy(k.previous[:], k.current[:])
 }
 
 func (k *keys) handleEvent(e *sdl.KeyboardEvent) {
-	switch e.Type {
-	case sdl.KEYUP:
-		if key, ok := keyMap[e.Keysym.Scancode]; ok {
-			k.current[key] = false
-		}
-	case sdl.KEYDOWN:
-		if key, ok := keyMap[e.Keysym.Scancode]; ok {
-			k.current[key] = true
-		}
+	if key, ok := k.keyMap[e.Keysym.Sym]; ok {
+		k.press(hostInput{keycode: e.Keysym.Sym}, key, e.Type == sdl.KEYDOWN)
 	}
 }
 
+// keySource lets the machine's input switch from a movie to the keyboard
+// when the movie ends.
+type keySource struct {
+	chip8.Keys
+}
+
 func (k *keys) IsKeyDown(i uint8) bool {
 	return k.current[i]
 }
@@ -131,10 +272,22 @@ type window struct {
 	window     *sdl.Window
 	renderer   *sdl.Renderer
 	backbuffer *sdl.Texture
+
+	width  int
+	height int
+
+	title   string
+	fault   bool
+	palette [4]color.RGBA
+
+	// pixels is the last framebuffer drawn, kept for screenshots.
+	pixels [][]uint8
 }
 
-func newWindow(filename string) (*window, error) {
-	w, err := sdl.CreateWindow(fmt.Sprintf("Chip 8 - %s", filepath.Base(filename)), sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED, 640, 320, sdl.WINDOW_SHOWN)
+func newWindow(name string, palette [4]color.RGBA) (*window, error) {
+	title := fmt.Sprintf("Chip 8 - %s", name)
+
+	w, err := sdl.CreateWindow(title, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED, 640, 320, sdl.WINDOW_SHOWN)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create window: %v", err)
 	}
@@ -145,7 +298,7 @@ func newWindow(filename string) (*window, error) {
 		return nil, fmt.Errorf("failed to create renderer: %v", err)
 	}
 
-	backbuffer, err := renderer.CreateTexture(sdl.PIXELFORMAT_ABGR8888, sdl.TEXTUREACCESS_TARGET, int32(display.DisplayWidth), int32(display.DisplayHeight))
+	backbuffer, err := renderer.CreateTexture(sdl.PIXELFORMAT_ABGR8888, sdl.TEXTUREACCESS_TARGET, int32(display.LowResWidth), int32(display.LowResHeight))
 	if err != nil {
 		_ = renderer.Destroy()
 		_ = w.Destroy()
@@ -156,15 +309,48 @@ func newWindow(filename string) (*window, error) {
 		window:     w,
 		renderer:   renderer,
 		backbuffer: backbuffer,
+
+		width:  display.LowResWidth,
+		height: display.LowResHeight,
+
+		title:   title,
+		palette: palette,
 	}, nil
 }
 
+func (d *window) resize(width, height int) error {
+	backbuffer, err := d.renderer.CreateTexture(sdl.PIXELFORMAT_ABGR8888, sdl.TEXTUREACCESS_TARGET, int32(width), int32(height))
+	if err != nil {
+		return fmt.Errorf("failed to create backbuffer: %v", err)
+	}
+
+	_ = d.backbuffer.Destroy()
+
+	d.backbuffer = backbuffer
+	d.width = width
+	d.height = height
+
+	return nil
+}
+
 func (d *window) destroy() {
 	_ = d.backbuffer.Destroy()
 	_ = d.renderer.Destroy()
 	_ = d.window.Destroy()
 }
 
+// showFault tints the frozen frame red and shows err in the title bar, or
+// restores the window if err is nil.
+func (d *window) showFault(err error) {
+	d.fault = err != nil
+
+	if err != nil {
+		d.window.SetTitle(fmt.Sprintf("%s - %v", d.title, err))
+	} else {
+		d.window.SetTitle(d.title)
+	}
+}
+
 func (d *window) present() error {
 	if err := d.renderer.SetDrawColor(255, 0, 0, 255); err != nil {
 		return fmt.Errorf("failed to set draw color: %v", err)
@@ -174,6 +360,15 @@ func (d *window) present() error {
 		return fmt.Errorf("failed to clear: %v", err)
 	}
 
+	tint := uint8(255)
+	if d.fault {
+		tint = 96
+	}
+
+	if err := d.backbuffer.SetColorMod(255, tint, tint); err != nil {
+		return fmt.Errorf("failed to set color mod: %v", err)
+	}
+
 	if err := d.renderer.Copy(d.backbuffer, nil, nil); err != nil {
 		return fmt.Errorf("failed to copy backbuffer: %v", err)
 	}
@@ -183,7 +378,25 @@ func (d *window) present() error {
 	return nil
 }
 
-func (d *window) Draw(pixels [display.DisplayHeight][display.DisplayWidth]bool) error {
+// screenshot saves the last framebuffer drawn to a PNG in dir, named after
+// the ROM. Failures are reported without stopping the emulator.
+func (d *window) screenshot(dir, rom string, scale int) {
+	path, err := screenshot.Save(dir, rom, d.pixels, screenshot.Options{Scale: scale, Palette: &d.palette})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+
+	fmt.Printf("saved screenshot to %s\n", path)
+}
+
+func (d *window) Draw(pixels [][]uint8) error {
+	if len(pixels) != d.height || len(pixels[0]) != d.width {
+		if err := d.resize(len(pixels[0]), len(pixels)); err != nil {
+			return err
+		}
+	}
+
 	target := d.renderer.GetRenderTarget()
 
 	if err := d.renderer.SetRenderTarget(d.backbuffer); err != nil {
@@ -192,14 +405,9 @@ func (d *window) Draw(pixels [display.DisplayHeight][display.DisplayWidth]bool)
 
 	for y := range pixels {
 		for x := range pixels[y] {
-			if pixels[y][x] {
-				if err := d.renderer.SetDrawColor(0, 0, 0, 255); err != nil {
-					return fmt.Errorf("failed to set draw color: %v", err)
-				}
-			} else {
-				if err := d.renderer.SetDrawColor(255, 255, 255, 255); err != nil {
-					return fmt.Errorf("failed to set draw color: %v", err)
-				}
+			colour := d.palette[pixels[y][x]&3]
+			if err := d.renderer.SetDrawColor(colour.R, colour.G, colour.B, 255); err != nil {
+				return fmt.Errorf("failed to set draw color: %v", err)
 			}
 
 			if err := d.renderer.DrawPoint(int32(x), int32(y)); err != nil {
@@ -212,50 +420,287 @@ func (d *window) Draw(pixels [display.DisplayHeight][display.DisplayWidth]bool)
 		return fmt.Errorf("failed to restore render target: %v", err)
 	}
 
+	d.pixels = make([][]uint8, len(pixels))
+	for y := range pixels {
+		d.pixels[y] = append([]uint8(nil), pixels[y]...)
+	}
+
 	return nil
 }
 
-func Run(filename string) error {
+// Options left unset are taken from the settings stored in an Octo cartridge
+// or found in Database, then from the defaults.
+type Options struct {
+	frontend.Settings
+
+	// Audio configures the tone played while the sound timer runs. It
+	// defaults to audio.DefaultOptions.
+	Audio audio.Options
+	// RecordAudio, if set, is written the session's sound as unsigned 8-bit
+	// samples at audio.DefaultSampleRate, one frame at a time.
+	RecordAudio io.Writer
+
+	// StickThreshold is how far, from 0 to 1, an analog stick or trigger
+	// must move to press a key. It defaults to DefaultStickThreshold.
+	StickThreshold float64
+
+	// ScreenshotDir is where screenshots are saved, defaulting to the current
+	// directory. ScreenshotScale is the size of a CHIP-8 pixel in them, and
+	// defaults to DefaultScreenshotScale.
+	ScreenshotDir   string
+	ScreenshotScale int
+
+	// RecordGIF, if set, is written the session as an animated GIF.
+	// RecordFrames, if set, is a directory to write every frame to as a PNG.
+	// Both draw pixels at ScreenshotScale, and skip frames spent paused or
+	// rewinding.
+	RecordGIF    io.Writer
+	RecordFrames string
+
+	// RewindDepth is the number of frames that can be rewound.
+	RewindDepth int
+	// RewindBudget is the number of bytes the rewind history may use.
+	RewindBudget int
+
+	// VIPInterpreter, if set, is an image of the COSMAC VIP's interpreter
+	// for chip8.VIPRandom to generate CXNN's numbers with.
+	VIPInterpreter []byte
+
+	// RecordMovie, if set, is written a movie of the session's input.
+	RecordMovie io.Writer
+	// PlayMovie, if set, is read a movie to replay. The movie's settings
+	// replace the quirks, speed and load address, and once it ends the
+	// keyboard takes over.
+	PlayMovie io.Reader
+
+	// Debug starts the machine paused with a debugger REPL on the terminal.
+	Debug bool
+
+	// Trace is called after every instruction, if set.
+	Trace func(chip8.Step)
+}
+
+func asFault(err error) (chip8.Fault, bool) {
+	var fault chip8.Fault
+	ok := errors.As(err, &fault)
+
+	return fault, ok
+}
+
+func Run(filename string, options Options) error {
+	rom, settings, err := romfile.LoadWithSettings(filename, options.Database)
+	if err != nil {
+		return err
+	}
+
+	options.Settings.Apply(settings)
+
+	var player *movie.Player
+	if options.PlayMovie != nil {
+		player, err = movie.NewPlayer(options.PlayMovie)
+		if err != nil {
+			return err
+		}
+
+		if err := player.Header.Check(rom); err != nil {
+			return err
+		}
+
+		options.Quirks = &player.Header.Quirks
+		options.CyclesPerFrame = player.Header.CyclesPerFrame
+		options.LoadAddress = player.Header.LoadAddress
+	}
+
+	seed := options.Seed
+	if player != nil {
+		seed = player.Header.Seed
+	} else if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	random := chip8.NewRandom(rand.NewSource(seed))
+	if options.VIPInterpreter != nil {
+		vip, err := chip8.NewVIPRandom(options.VIPInterpreter)
+		if err != nil {
+			return err
+		}
+
+		vip.Seed(seed)
+		random = vip
+	}
+
+	if player != nil {
+		if err := player.Header.CheckRandom(random); err != nil {
+			return err
+		}
+	}
+
+	name := filepath.Base(filename)
+	if settings != nil && settings.Title != "" {
+		name = settings.Title
+	}
+
+	var inputs map[string]uint8
+	if settings != nil {
+		inputs = settings.Keys
+	}
+
 	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
 		return fmt.Errorf("failed to init SDL: %v", err)
 	}
 	defer sdl.Quit()
 
-	keys := &keys{}
+	layout, err := options.KeypadLayout(rom)
+	if err != nil {
+		return err
+	}
 
-	beeper, err := newBeeper()
+	if options.ScreenshotScale == 0 {
+		options.ScreenshotScale = DefaultScreenshotScale
+	}
+
+	threshold := options.StickThreshold
+	if threshold == 0 {
+		threshold = DefaultStickThreshold
+	}
+
+	keys, err := newKeys(layout, inputs, threshold)
+	if err != nil {
+		return err
+	}
+	defer keys.destroy()
+
+	if options.Audio == (audio.Options{}) {
+		options.Audio = audio.DefaultOptions
+	}
+
+	beeper, err := newBeeper(options.Audio, options.RecordAudio)
 	if err != nil {
 		return fmt.Errorf("failed to init beeper: %v", err)
 	}
 	defer beeper.destroy()
 
-	window, err := newWindow(filename)
+	window, err := newWindow(name, *options.Palette)
 	if err != nil {
 		return fmt.Errorf("failed to init window: %v", err)
 	}
 	defer window.destroy()
 
-	chip8, err := chip8.New(keys, beeper, window)
+	imageOptions := screenshot.Options{Scale: options.ScreenshotScale, Palette: options.Palette}
+
+	var encoder capture.Encoder
+	if options.RecordGIF != nil {
+		encoder = capture.NewGIF(options.RecordGIF, imageOptions)
+	} else if options.RecordFrames != "" {
+		encoder, err = capture.NewFrames(options.RecordFrames, imageOptions)
+		if err != nil {
+			return err
+		}
+	}
+
+	var drawer display.Drawer = window
+
+	var recording *capture.Drawer
+	if encoder != nil {
+		defer encoder.Close()
+
+		recording = capture.NewDrawer(window, encoder)
+		drawer = recording
+	}
+
+	input := &keySource{Keys: keys}
+	if player != nil {
+		input.Keys = player
+	}
+
+	chip8, err := chip8.New(input, beeper, drawer, *options.Quirks, random)
 	if err != nil {
 		return fmt.Errorf("failed to init chip8: %v", err)
 	}
 
-	rom, err := os.Open(filename)
+	err = chip8.LoadROMAt(bytes.NewReader(rom), options.LoadAddress)
 	if err != nil {
-		return fmt.Errorf("failed to open ROM file: %v", err)
+		return fmt.Errorf("failed to load ROM file: %v", err)
 	}
 
-	defer rom.Close()
-
-	err = chip8.LoadROM(rom)
+	flags, err := frontend.LoadRPLFlags(filename)
 	if err != nil {
-		return fmt.Errorf("failed to load ROM file: %v", err)
+		return err
+	}
+
+	if player != nil {
+		flags = player.Header.RPLFlags
+	}
+
+	var recorder *movie.Writer
+	if options.RecordMovie != nil {
+		recorder, err = movie.NewWriter(options.RecordMovie, movie.Header{
+			ROMHash:        sha1.Sum(rom),
+			Quirks:         *options.Quirks,
+			Seed:           seed,
+			CyclesPerFrame: options.CyclesPerFrame,
+			LoadAddress:    options.LoadAddress,
+			RPLFlags:       flags,
+			VIPRandom:      options.VIPInterpreter != nil,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	chip8.SetRPLFlags(flags)
+
+	// Rewinding or loading a slot would make the run differ from its movie.
+	movieActive := player != nil || recorder != nil
+	replaying := player != nil
+
+	persistRPLFlags := func() error {
+		// A replay leaves the flags saved by real play alone.
+		if replaying || chip8.RPLFlags() == flags {
+			return nil
+		}
+
+		return frontend.SaveRPLFlags(filename, chip8.RPLFlags())
+	}
+
+	// finish saves the flags and completes the recording when the session
+	// ends normally.
+	finish := func() error {
+		if err := persistRPLFlags(); err != nil {
+			return err
+		}
+
+		if encoder != nil {
+			return encoder.Close()
+		}
+
+		return nil
+	}
+
+	if options.Trace != nil {
+		chip8.SetTraceHook(options.Trace)
 	}
 
+	runFrame := chip8.RunFrame
+
+	var debug *debugger.Debugger
+	if options.Debug {
+		debug = debugger.New(chip8, os.Stdout)
+		go debug.Serve(os.Stdin)
+
+		runFrame = debug.RunFrame
+	}
+
+	history := rewind.New(options.RewindDepth, options.RewindBudget)
+	rewinding := false
+	faulted := false
+
+	var state bytes.Buffer
+
 	currentTime := time.Now()
 	accumulator := time.Duration(0)
 
-	dt := time.Duration(time.Second.Nanoseconds() / cyclesPerSecond)
+	dt := time.Duration(time.Second.Nanoseconds() / framesPerSecond)
 
 	for {
 		now := time.Now()
@@ -271,18 +716,126 @@ func Run(filename string) error {
 			for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
 				switch e := event.(type) {
 				case *sdl.QuitEvent:
-					return nil
+					return finish()
+				case *sdl.ControllerDeviceEvent:
+					keys.handleControllerDevice(e)
+				case *sdl.ControllerButtonEvent:
+					keys.handleControllerButton(e)
+				case *sdl.ControllerAxisEvent:
+					keys.handleControllerAxis(e)
 				case *sdl.KeyboardEvent:
 					keys.handleEvent(e)
+
+					if e.Type == sdl.KEYDOWN && e.Repeat == 0 && e.Keysym.Scancode == screenshotKey {
+						window.screenshot(options.ScreenshotDir, filename, options.ScreenshotScale)
+					}
+
+					if movieActive {
+						break
+					}
+
+					if handleSlotKey(chip8, filename, e) && faulted {
+						faulted = false
+						window.showFault(nil)
+					}
+
+					if e.Keysym.Scancode == rewindKey {
+						rewinding = e.Type == sdl.KEYDOWN
+					}
 				}
 
 			}
 
-			err = chip8.Cycle()
-			if err != nil {
-				return fmt.Errorf("failed to cycle: %v", err)
+			if rewinding {
+				beeper.silence()
+
+				if history.Len() > 1 {
+					history.Pop()
+					previous, _ := history.Peek()
+
+					if err := chip8.LoadState(bytes.NewReader(previous)); err != nil {
+						return fmt.Errorf("failed to rewind: %v", err)
+					}
+
+					if faulted {
+						faulted = false
+						window.showFault(nil)
+					}
+				}
+
+				accumulator -= dt
+				continue
+			}
+
+			// A faulted machine stays frozen on the fault screen until it is
+			// rewound or the window is closed.
+			if faulted {
+				accumulator -= dt
+				continue
+			}
+
+			paused := debug != nil && debug.Paused()
+
+			if player != nil {
+				err := player.Next()
+				if err == io.EOF {
+					fmt.Printf("movie ended after %d frames\n", player.Frames())
+
+					input.Keys = keys
+					player = nil
+				} else if err != nil {
+					return err
+				}
+			}
+
+			if recorder != nil && !paused {
+				if err := recorder.WriteFrame(keys.current); err != nil {
+					return err
+				}
+			}
+
+			err = runFrame(options.CyclesPerFrame)
+			if fault, ok := asFault(err); ok {
+				fmt.Fprintln(os.Stderr, fault)
+				window.showFault(fault)
+				beeper.silence()
+				faulted = true
+
+				accumulator -= dt
+				continue
+			} else if err != nil {
+				return fmt.Errorf("failed to run frame: %v", err)
 			}
 
+			if err := beeper.err(); err != nil {
+				return err
+			}
+
+			if recording != nil && !paused {
+				if err := recording.EndFrame(); err != nil {
+					return err
+				}
+			}
+
+			if chip8.Halted() {
+				return finish()
+			}
+
+			// Keep the rewind history free of repeats of the paused frame.
+			if paused {
+				beeper.silence()
+
+				accumulator -= dt
+				continue
+			}
+
+			state.Reset()
+			if err := chip8.SaveState(&state); err != nil {
+				return fmt.Errorf("failed to save rewind state: %v", err)
+			}
+
+			history.Push(state.Bytes())
+
 			accumulator -= dt
 		}
 
@@ -0,0 +1,80 @@
+package emulator
+
+import (
+	"chip8/chip8"
+	"fmt"
+	"os"
+
+	sdl "github.com/veandco/go-sdl2/sdl"
+)
+
+// slotKeys binds the function keys to save slots: pressing a key loads the
+// slot and holding shift while pressing it saves to the slot.
+var slotKeys = map[sdl.Scancode]int{
+	sdl.SCANCODE_F1: 1,
+	sdl.SCANCODE_F2: 2,
+	sdl.SCANCODE_F3: 3,
+	sdl.SCANCODE_F4: 4,
+	sdl.SCANCODE_F5: 5,
+	sdl.SCANCODE_F6: 6,
+	sdl.SCANCODE_F7: 7,
+	sdl.SCANCODE_F8: 8,
+	sdl.SCANCODE_F9: 9,
+}
+
+func slotFilename(filename string, slot int) string {
+	return fmt.Sprintf("%s.state%d", filename, slot)
+}
+
+func saveSlot(c *chip8.Chip8, filename string, slot int) error {
+	f, err := os.Create(slotFilename(filename, slot))
+	if err != nil {
+		return fmt.Errorf("failed to create save slot %d: %v", slot, err)
+	}
+
+	if err := c.SaveState(f); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to save slot %d: %v", slot, err)
+	}
+
+	return f.Close()
+}
+
+func loadSlot(c *chip8.Chip8, filename string, slot int) error {
+	f, err := os.Open(slotFilename(filename, slot))
+	if err != nil {
+		return fmt.Errorf("failed to open save slot %d: %v", slot, err)
+	}
+	defer f.Close()
+
+	if err := c.LoadState(f); err != nil {
+		return fmt.Errorf("failed to load slot %d: %v", slot, err)
+	}
+
+	return nil
+}
+
+// handleSlotKey saves or loads a slot if e is a key down on a slot key, and
+// reports whether a slot was loaded. Failures are reported without stopping
+// the emulator.
+func handleSlotKey(c *chip8.Chip8, filename string, e *sdl.KeyboardEvent) bool {
+	slot, ok := slotKeys[e.Keysym.Scancode]
+	if !ok || e.Type != sdl.KEYDOWN || e.Repeat != 0 {
+		return false
+	}
+
+	if e.Keysym.Mod&sdl.KMOD_SHIFT != 0 {
+		if err := saveSlot(c, filename, slot); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+
+		return false
+	}
+
+	if err := loadSlot(c, filename, slot); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+
+	return true
+}
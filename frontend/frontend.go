@@ -0,0 +1,133 @@
+// Package frontend holds what the SDL and terminal frontends share: their
+// common options, the machine settings a ROM can supply and their defaults,
+// and the RPL flags kept next to the ROM between runs.
+package frontend
+
+import (
+	"chip8/chip8"
+	"chip8/database"
+	"chip8/keymap"
+	"chip8/screenshot"
+	"crypto/sha1"
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"os"
+)
+
+// DefaultCyclesPerFrame runs roughly 500 instructions per second.
+const DefaultCyclesPerFrame = 8
+
+// Settings are the options both frontends take. Quirks, LoadAddress,
+// CyclesPerFrame and Palette, if left unset, are taken from the ROM's
+// settings, then the defaults.
+type Settings struct {
+	// Quirks defaults to chip8.QuirksLegacy.
+	Quirks *chip8.Quirks
+
+	// LoadAddress is the address the ROM is loaded and started at. It
+	// defaults to chip8.DefaultLoadAddress.
+	LoadAddress uint16
+
+	// CyclesPerFrame is the number of instructions executed per 60 Hz frame.
+	// It defaults to DefaultCyclesPerFrame.
+	CyclesPerFrame int
+
+	// Palette holds the colours of pixel values 0 to 3. It defaults to
+	// screenshot.DefaultPalette.
+	Palette *[4]color.RGBA
+
+	// Database is searched for the ROM's settings, if set.
+	Database *database.Database
+
+	// Keymap chooses the keypad layout for the ROM. Layout, if set, names
+	// the layout to use for every ROM instead.
+	Keymap *keymap.Config
+	Layout string
+
+	// Seed seeds CXNN's random numbers. Zero picks a seed from the time.
+	Seed int64
+}
+
+// Apply fills in the settings left unset from entry, the ROM's settings, and
+// then the defaults. entry is nil for a ROM with no settings.
+func (s *Settings) Apply(entry *database.Entry) {
+	if entry == nil {
+		entry = &database.Entry{Quirks: chip8.QuirksLegacy}
+	}
+
+	if s.Quirks == nil {
+		q := entry.Quirks
+		s.Quirks = &q
+	}
+
+	if s.LoadAddress == 0 {
+		s.LoadAddress = entry.StartAddress
+	}
+
+	if s.LoadAddress == 0 {
+		s.LoadAddress = chip8.DefaultLoadAddress
+	}
+
+	if s.CyclesPerFrame == 0 {
+		s.CyclesPerFrame = entry.Tickrate
+	}
+
+	if s.CyclesPerFrame == 0 {
+		s.CyclesPerFrame = DefaultCyclesPerFrame
+	}
+
+	if s.Palette == nil {
+		palette := screenshot.DefaultPalette
+		copy(palette[:], entry.Palette)
+
+		s.Palette = &palette
+	}
+}
+
+// KeypadLayout returns the keypad layout to play rom with.
+func (s *Settings) KeypadLayout(rom []byte) (keymap.Layout, error) {
+	km := s.Keymap
+	if km == nil {
+		km = &keymap.Config{}
+	}
+
+	if s.Layout != "" {
+		return km.Find(s.Layout)
+	}
+
+	return km.Select(sha1.Sum(rom))
+}
+
+// rplFilename is where the RPL flags of the ROM in filename are kept.
+func rplFilename(rom string) string {
+	return rom + ".rpl"
+}
+
+// LoadRPLFlags reads the RPL flags saved for the ROM in filename. A ROM that
+// has never saved any has all its flags zero.
+func LoadRPLFlags(filename string) ([16]uint8, error) {
+	var flags [16]uint8
+
+	b, err := ioutil.ReadFile(rplFilename(filename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return flags, nil
+		}
+
+		return flags, fmt.Errorf("failed to read RPL flags: %v", err)
+	}
+
+	copy(flags[:], b)
+
+	return flags, nil
+}
+
+// SaveRPLFlags writes the RPL flags of the ROM in filename next to it.
+func SaveRPLFlags(filename string, flags [16]uint8) error {
+	if err := ioutil.WriteFile(rplFilename(filename), flags[:], 0644); err != nil {
+		return fmt.Errorf("failed to write RPL flags: %v", err)
+	}
+
+	return nil
+}
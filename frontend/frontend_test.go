@@ -0,0 +1,81 @@
+package frontend_test
+
+import (
+	"chip8/chip8"
+	"chip8/database"
+	"chip8/frontend"
+	"chip8/keymap"
+	"chip8/screenshot"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type FrontendSuite struct {
+	suite.Suite
+}
+
+func (suite *FrontendSuite) TestDefaults() {
+	var s frontend.Settings
+	s.Apply(nil)
+
+	suite.Assert().Equal(chip8.QuirksLegacy, *s.Quirks)
+	suite.Assert().Equal(uint16(chip8.DefaultLoadAddress), s.LoadAddress)
+	suite.Assert().Equal(frontend.DefaultCyclesPerFrame, s.CyclesPerFrame)
+	suite.Assert().Equal(screenshot.DefaultPalette, *s.Palette)
+}
+
+func (suite *FrontendSuite) TestEntry() {
+	q := chip8.QuirksVIP
+	s := frontend.Settings{Quirks: &q}
+	s.Apply(&database.Entry{
+		Quirks:       chip8.QuirksSuperChip,
+		Tickrate:     30,
+		StartAddress: 0x600,
+		Palette:      []color.RGBA{{0, 0, 0, 255}},
+	})
+
+	suite.Assert().Equal(chip8.QuirksVIP, *s.Quirks)
+	suite.Assert().Equal(uint16(0x600), s.LoadAddress)
+	suite.Assert().Equal(30, s.CyclesPerFrame)
+	suite.Assert().Equal(color.RGBA{0, 0, 0, 255}, s.Palette[0])
+	suite.Assert().Equal(screenshot.DefaultPalette[1], s.Palette[1])
+}
+
+func (suite *FrontendSuite) TestKeypadLayout() {
+	var s frontend.Settings
+
+	layout, err := s.KeypadLayout([]byte{0x12, 0x00})
+	suite.Require().Nil(err)
+	suite.Assert().Equal(keymap.Layouts[keymap.DefaultLayout], layout)
+
+	s.Layout = "unknown layout"
+	_, err = s.KeypadLayout([]byte{0x12, 0x00})
+	suite.Assert().Error(err)
+}
+
+func (suite *FrontendSuite) TestRPLFlags() {
+	dir, err := ioutil.TempDir("", "frontend")
+	suite.Require().Nil(err)
+	defer os.RemoveAll(dir)
+
+	rom := filepath.Join(dir, "game.ch8")
+
+	flags, err := frontend.LoadRPLFlags(rom)
+	suite.Require().Nil(err)
+	suite.Assert().Equal([16]uint8{}, flags)
+
+	suite.Require().Nil(frontend.SaveRPLFlags(rom, [16]uint8{1, 2, 3}))
+
+	flags, err = frontend.LoadRPLFlags(rom)
+	suite.Require().Nil(err)
+	suite.Assert().Equal([16]uint8{1, 2, 3}, flags)
+}
+
+func TestFrontend(t *testing.T) {
+	suite.Run(t, new(FrontendSuite))
+}
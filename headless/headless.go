@@ -0,0 +1,334 @@
+// Package headless runs CHIP-8 programs without a window or audio device,
+// for use from tests and tools.
+package headless
+
+import (
+	"bytes"
+	"chip8/audio"
+	"chip8/capture"
+	"chip8/chip8"
+	"chip8/chip8/display"
+	"chip8/frontend"
+	"chip8/movie"
+	"chip8/screenshot"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrFrameLimit is returned by RunUntil when the condition is not met in time.
+var ErrFrameLimit = errors.New("frame limit reached")
+
+// Keys is an in-memory keypad. Keys pressed or released between frames are
+// seen by the program on the next frame.
+type Keys struct {
+	current  [16]bool
+	previous [16]bool
+}
+
+func (k *Keys) endFrame() {
+	copy(k.previous[:], k.current[:])
+}
+
+func (k *Keys) Press(i uint8) {
+	k.current[i&0xF] = true
+}
+
+func (k *Keys) Release(i uint8) {
+	k.current[i&0xF] = false
+}
+
+func (k *Keys) IsKeyDown(i uint8) bool {
+	return k.current[i&0xF]
+}
+
+func (k *Keys) WasKeyReleased(i uint8) bool {
+	return !k.current[i&0xF] && k.previous[i&0xF]
+}
+
+// Beeper records when the program's sound is on, and passes the sound to the
+// audio recorder if there is one.
+type Beeper struct {
+	// On is whether the sound is playing.
+	On bool
+	// Beeps counts the times the sound started.
+	Beeps int
+	// Frames counts the frames the sound was on for.
+	Frames int
+
+	recorder *audio.Recorder
+}
+
+func (b *Beeper) SetSound(on bool) {
+	if b.recorder != nil {
+		b.recorder.SetSound(on)
+	}
+
+	if on {
+		if !b.On {
+			b.Beeps++
+		}
+
+		b.Frames++
+	}
+
+	b.On = on
+}
+
+func (b *Beeper) SetPattern(pattern [16]uint8, pitch uint8) {
+	if b.recorder != nil {
+		b.recorder.SetPattern(pattern, pitch)
+	}
+}
+
+// Drawer keeps a copy of the most recently drawn framebuffer.
+type Drawer struct {
+	pixels [][]uint8
+}
+
+func NewDrawer() *Drawer {
+	pixels := make([][]uint8, display.LowResHeight)
+	for y := range pixels {
+		pixels[y] = make([]uint8, display.LowResWidth)
+	}
+
+	return &Drawer{pixels: pixels}
+}
+
+func (d *Drawer) Draw(pixels [][]uint8) error {
+	d.pixels = make([][]uint8, len(pixels))
+	for y := range pixels {
+		d.pixels[y] = append([]uint8(nil), pixels[y]...)
+	}
+
+	return nil
+}
+
+// Pixels returns a copy of the framebuffer.
+func (d *Drawer) Pixels() [][]uint8 {
+	pixels := make([][]uint8, len(d.pixels))
+	for y := range d.pixels {
+		pixels[y] = append([]uint8(nil), d.pixels[y]...)
+	}
+
+	return pixels
+}
+
+type Options struct {
+	// Quirks defaults to chip8.QuirksLegacy, as in the frontends.
+	Quirks *chip8.Quirks
+
+	// LoadAddress is the address the ROM is loaded and started at. Zero means
+	// chip8.DefaultLoadAddress.
+	LoadAddress uint16
+
+	// CyclesPerFrame is the number of instructions executed per frame. Zero
+	// means frontend.DefaultCyclesPerFrame, as in the frontends.
+	CyclesPerFrame int
+
+	// Random supplies CXNN's numbers. It defaults to math/rand.
+	Random chip8.Random
+	// Seed seeds Random, so runs are repeatable. Unlike the frontends, zero
+	// is a seed like any other rather than a request for a different seed
+	// every run.
+	Seed int64
+	// RPLFlags are the persistent flags FX85 reads.
+	RPLFlags [16]uint8
+
+	// RecordAudio, if set, is written the program's sound as unsigned 8-bit
+	// samples at audio.DefaultSampleRate, played with the Audio options or
+	// audio.DefaultOptions.
+	RecordAudio io.Writer
+	Audio       audio.Options
+
+	// Capture, if set, is given the framebuffer at the end of every frame.
+	// The caller closes it.
+	Capture capture.Encoder
+}
+
+// Runner drives a Chip8 frame by frame.
+type Runner struct {
+	Keys   *Keys
+	Beeper *Beeper
+	Drawer *Drawer
+	Chip8  *chip8.Chip8
+
+	capture *capture.Drawer
+
+	options Options
+	frames  int
+}
+
+// New creates a runner with rom loaded.
+func New(rom io.Reader, options Options) (*Runner, error) {
+	if options.Quirks == nil {
+		options.Quirks = &chip8.QuirksLegacy
+	}
+
+	if options.CyclesPerFrame == 0 {
+		options.CyclesPerFrame = frontend.DefaultCyclesPerFrame
+	}
+
+	r := &Runner{
+		Keys:   &Keys{},
+		Beeper: &Beeper{},
+		Drawer: NewDrawer(),
+
+		options: options,
+	}
+
+	if options.RecordAudio != nil {
+		a := options.Audio
+		if a == (audio.Options{}) {
+			a = audio.DefaultOptions
+		}
+
+		r.Beeper.recorder = audio.NewRecorder(options.RecordAudio, audio.DefaultSampleRate, a)
+	}
+
+	var drawer display.Drawer = r.Drawer
+	if options.Capture != nil {
+		r.capture = capture.NewDrawer(r.Drawer, options.Capture)
+		drawer = r.capture
+	}
+
+	c, err := chip8.New(r.Keys, r.Beeper, drawer, *options.Quirks, options.Random)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init chip8: %v", err)
+	}
+
+	loadAddress := options.LoadAddress
+	if loadAddress == 0 {
+		loadAddress = chip8.DefaultLoadAddress
+	}
+
+	if err := c.LoadROMAt(rom, loadAddress); err != nil {
+		return nil, fmt.Errorf("failed to load ROM: %v", err)
+	}
+
+	c.Seed(options.Seed)
+	c.SetRPLFlags(options.RPLFlags)
+
+	r.Chip8 = c
+
+	return r, nil
+}
+
+// NewFromMovie creates a runner with rom loaded, set up as the movie was
+// recorded. The movie's settings replace those in options, which supplies the
+// rest, such as the VIPRandom a movie recorded with one needs. Play the movie
+// with PlayMovie.
+func NewFromMovie(rom []byte, p *movie.Player, options Options) (*Runner, error) {
+	if err := p.Header.Check(rom); err != nil {
+		return nil, err
+	}
+
+	if err := p.Header.CheckRandom(options.Random); err != nil {
+		return nil, err
+	}
+
+	quirks := p.Header.Quirks
+	options.Quirks = &quirks
+	options.LoadAddress = p.Header.LoadAddress
+	options.CyclesPerFrame = p.Header.CyclesPerFrame
+	options.Seed = p.Header.Seed
+	options.RPLFlags = p.Header.RPLFlags
+
+	return New(bytes.NewReader(rom), options)
+}
+
+// PlayMovie runs a frame with the keys from each frame of the movie, stopping
+// early if the program exits.
+func (r *Runner) PlayMovie(p *movie.Player) error {
+	for !r.Chip8.Halted() {
+		err := p.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		r.Keys.current = p.Keys()
+
+		if err := r.RunFrame(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Frames returns the number of frames run so far.
+func (r *Runner) Frames() int {
+	return r.frames
+}
+
+// Framebuffer returns a copy of the current framebuffer.
+func (r *Runner) Framebuffer() [][]uint8 {
+	return r.Drawer.Pixels()
+}
+
+// Screenshot writes the current framebuffer to w as a PNG.
+func (r *Runner) Screenshot(w io.Writer, options screenshot.Options) error {
+	return screenshot.Encode(w, r.Drawer.pixels, options)
+}
+
+// RunFrame runs a single frame.
+func (r *Runner) RunFrame() error {
+	if err := r.Chip8.RunFrame(r.options.CyclesPerFrame); err != nil {
+		return fmt.Errorf("frame %d: %w", r.frames, err)
+	}
+
+	if r.Beeper.recorder != nil {
+		if err := r.Beeper.recorder.Err(); err != nil {
+			return err
+		}
+	}
+
+	if r.capture != nil {
+		if err := r.capture.EndFrame(); err != nil {
+			return err
+		}
+	}
+
+	r.Keys.endFrame()
+	r.frames++
+
+	return nil
+}
+
+// RunFrames runs n frames, stopping early if the program exits.
+func (r *Runner) RunFrames(n int) error {
+	for i := 0; i < n && !r.Chip8.Halted(); i++ {
+		if err := r.RunFrame(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RunUntil runs frames until done returns true, checking before each frame.
+// It returns ErrFrameLimit if done is still false after maxFrames frames or
+// the program exits first.
+func (r *Runner) RunUntil(done func(r *Runner) bool, maxFrames int) error {
+	for i := 0; i < maxFrames; i++ {
+		if done(r) {
+			return nil
+		}
+
+		if r.Chip8.Halted() {
+			break
+		}
+
+		if err := r.RunFrame(); err != nil {
+			return err
+		}
+	}
+
+	if done(r) {
+		return nil
+	}
+
+	return ErrFrameLimit
+}
@@ -0,0 +1,229 @@
+package headless_test
+
+import (
+	"bytes"
+	"chip8/audio"
+	"chip8/capture"
+	"chip8/chip8"
+	"chip8/frontend"
+	"chip8/headless"
+	"chip8/movie"
+	"chip8/screenshot"
+	"crypto/sha1"
+	"image/gif"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// drawZero draws the font glyph for 0 in the top-left corner and loops.
+var drawZero = []byte{
+	0x00, 0xE0, // clear screen
+	0x60, 0x00, // v0 := 0
+	0xF0, 0x29, // i := font v0
+	0xD0, 0x05, // draw v0 v0 5
+	0x12, 0x08, // loop
+}
+
+// waitForKey waits for key 5 to be released and then exits.
+var waitForKey = []byte{
+	0xF0, 0x0A, // v0 := key
+	0x00, 0xFD, // exit
+}
+
+type RunnerSuite struct {
+	suite.Suite
+}
+
+func (suite *RunnerSuite) TestFramebuffer() {
+	r, err := headless.New(bytes.NewReader(drawZero), headless.Options{Quirks: &chip8.QuirksCHIP48, CyclesPerFrame: 10})
+	suite.Require().Nil(err)
+
+	suite.Require().Nil(r.RunFrames(2))
+	suite.Assert().Equal(2, r.Frames())
+
+	pixels := r.Framebuffer()
+	suite.Assert().Equal([]uint8{1, 1, 1, 1, 0}, pixels[0][:5])
+	suite.Assert().Equal([]uint8{1, 0, 0, 1, 0}, pixels[1][:5])
+	suite.Assert().Equal([]uint8{1, 1, 1, 1, 0}, pixels[4][:5])
+}
+
+func (suite *RunnerSuite) TestScreenshot() {
+	r, err := headless.New(bytes.NewReader(drawZero), headless.Options{Quirks: &chip8.QuirksCHIP48, CyclesPerFrame: 10})
+	suite.Require().Nil(err)
+	suite.Require().Nil(r.RunFrames(2))
+
+	var b bytes.Buffer
+	suite.Require().Nil(r.Screenshot(&b, screenshot.Options{Scale: 3}))
+
+	img, err := png.Decode(&b)
+	suite.Require().Nil(err)
+	suite.Assert().Equal(screenshot.Image(r.Framebuffer(), screenshot.Options{Scale: 3}), img)
+}
+
+func (suite *RunnerSuite) TestCapture() {
+	var b bytes.Buffer
+	g := capture.NewGIF(&b, screenshot.Options{})
+
+	r, err := headless.New(bytes.NewReader(drawZero), headless.Options{Quirks: &chip8.QuirksCHIP48, CyclesPerFrame: 10, Capture: g})
+	suite.Require().Nil(err)
+	suite.Require().Nil(r.RunFrames(60))
+	suite.Require().Nil(g.Close())
+
+	anim, err := gif.DecodeAll(&b)
+	suite.Require().Nil(err)
+
+	total := 0
+	for _, delay := range anim.Delay {
+		total += delay
+	}
+
+	suite.Assert().Equal(100, total)
+	suite.Assert().Equal(uint8(1), anim.Image[len(anim.Image)-1].ColorIndexAt(0, 0))
+}
+
+func (suite *RunnerSuite) TestRunUntil() {
+	r, err := headless.New(bytes.NewReader(waitForKey), headless.Options{Quirks: &chip8.QuirksSuperChip, CyclesPerFrame: 10})
+	suite.Require().Nil(err)
+
+	r.Keys.Press(5)
+	suite.Require().Nil(r.RunFrames(3))
+	r.Keys.Release(5)
+
+	err = r.RunUntil(func(r *headless.Runner) bool { return r.Chip8.Halted() }, 10)
+	suite.Assert().Nil(err)
+}
+
+func (suite *RunnerSuite) TestFrameLimit() {
+	r, err := headless.New(bytes.NewReader(waitForKey), headless.Options{Quirks: &chip8.QuirksSuperChip, CyclesPerFrame: 10})
+	suite.Require().Nil(err)
+
+	err = r.RunUntil(func(r *headless.Runner) bool { return r.Chip8.Halted() }, 10)
+	suite.Assert().ErrorIs(err, headless.ErrFrameLimit)
+	suite.Assert().Equal(10, r.Frames())
+}
+
+func (suite *RunnerSuite) TestDefaultCyclesPerFrame() {
+	program := []byte{
+		0x70, 0x01, // v0 += 1
+		0x12, 0x00, // loop
+	}
+
+	r, err := headless.New(bytes.NewReader(program), headless.Options{Quirks: &chip8.QuirksCHIP48})
+	suite.Require().Nil(err)
+
+	suite.Require().Nil(r.RunFrames(1))
+	suite.Assert().Equal(uint8(frontend.DefaultCyclesPerFrame/2), r.Chip8.Registers().V[0])
+}
+
+func (suite *RunnerSuite) TestDefaultQuirks() {
+	program := []byte{
+		0x61, 0x06, // v1 := 6
+		0x80, 0x16, // v0 := v1 >> 1
+		0x12, 0x04, // loop
+	}
+
+	r, err := headless.New(bytes.NewReader(program), headless.Options{})
+	suite.Require().Nil(err)
+
+	suite.Require().Nil(r.RunFrames(1))
+	suite.Assert().Equal(uint8(3), r.Chip8.Registers().V[0])
+}
+
+func (suite *RunnerSuite) TestSound() {
+	program := []byte{
+		0x60, 0x03, // v0 := 3
+		0xF0, 0x18, // buzzer := v0
+		0x12, 0x04, // loop
+	}
+
+	r, err := headless.New(bytes.NewReader(program), headless.Options{Quirks: &chip8.QuirksCHIP48, CyclesPerFrame: 10})
+	suite.Require().Nil(err)
+
+	suite.Require().Nil(r.RunFrames(5))
+	suite.Assert().False(r.Beeper.On)
+	suite.Assert().Equal(1, r.Beeper.Beeps)
+	suite.Assert().Equal(3, r.Beeper.Frames)
+}
+
+func (suite *RunnerSuite) TestRecordAudio() {
+	program := []byte{
+		0x60, 0x02, // v0 := 2
+		0xF0, 0x18, // buzzer := v0
+		0x12, 0x04, // loop
+	}
+
+	var samples bytes.Buffer
+	r, err := headless.New(bytes.NewReader(program), headless.Options{Quirks: &chip8.QuirksCHIP48, CyclesPerFrame: 10, RecordAudio: &samples})
+	suite.Require().Nil(err)
+
+	suite.Require().Nil(r.RunFrames(60))
+	suite.Require().Equal(audio.DefaultSampleRate, samples.Len())
+
+	frame := audio.DefaultSampleRate / 60
+	suite.Assert().NotEqual(bytes.Repeat([]byte{0x80}, 2*frame), samples.Bytes()[:2*frame])
+	suite.Assert().Equal(bytes.Repeat([]byte{0x80}, samples.Len()-3*frame), samples.Bytes()[3*frame:])
+}
+
+func (suite *RunnerSuite) TestPlayMovie() {
+	program := []byte{
+		0xC0, 0xFF, // v0 := random 0xFF
+		0xF1, 0x0A, // v1 := key
+		0xC2, 0xFF, // v2 := random 0xFF
+		0x00, 0xFD, // exit
+	}
+
+	options := headless.Options{Quirks: &chip8.QuirksSuperChip, CyclesPerFrame: 10, Seed: 1234}
+
+	live, err := headless.New(bytes.NewReader(program), options)
+	suite.Require().Nil(err)
+
+	var b bytes.Buffer
+	w, err := movie.NewWriter(&b, movie.Header{
+		ROMHash:        sha1.Sum(program),
+		Quirks:         *options.Quirks,
+		Seed:           options.Seed,
+		CyclesPerFrame: options.CyclesPerFrame,
+	})
+	suite.Require().Nil(err)
+
+	for frame := 0; !live.Chip8.Halted(); frame++ {
+		var keys [16]bool
+		keys[7] = frame >= 3 && frame < 5
+
+		if keys[7] {
+			live.Keys.Press(7)
+		} else {
+			live.Keys.Release(7)
+		}
+
+		suite.Require().Nil(w.WriteFrame(keys))
+		suite.Require().Nil(live.RunFrame())
+	}
+
+	p, err := movie.NewPlayer(&b)
+	suite.Require().Nil(err)
+
+	replay, err := headless.NewFromMovie(program, p, headless.Options{})
+	suite.Require().Nil(err)
+
+	suite.Require().Nil(replay.PlayMovie(p))
+	suite.Assert().True(replay.Chip8.Halted())
+	suite.Assert().Equal(live.Frames(), replay.Frames())
+	suite.Assert().Equal(live.Chip8.Registers(), replay.Chip8.Registers())
+	suite.Assert().Equal(uint8(7), replay.Chip8.Registers().V[1])
+
+	_, err = headless.NewFromMovie([]byte{0x00, 0xFD}, p, headless.Options{})
+	suite.Assert().ErrorIs(err, movie.ErrROMMismatch)
+
+	vip, err := chip8.NewVIPRandom(make([]byte, 512))
+	suite.Require().Nil(err)
+
+	_, err = headless.NewFromMovie(program, p, headless.Options{Random: vip})
+	suite.Assert().ErrorIs(err, movie.ErrRandom)
+}
+
+func TestRunner(t *testing.T) {
+	suite.Run(t, new(RunnerSuite))
+}
@@ -0,0 +1,207 @@
+// Package keymap maps host keys to the CHIP-8 hex keypad.
+//
+// Host keys are named as SDL names them, such as "Q", "Up" or "Keypad 4",
+// and are matched against the symbol on the key rather than its position, so
+// a layout lines up with the labels on the keyboard. Controller inputs are
+// named "Pad " and the SDL name of the button, or of the axis followed by - or
+// + for its direction: "Pad a", "Pad dpup", "Pad leftx-".
+package keymap
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLayout is used when no layout is chosen.
+const DefaultLayout = "qwerty"
+
+// Layout lists the host keys that press each CHIP-8 key. In JSON it is an
+// object keyed by hex digit:
+//
+//	{"5": ["W", "Up"], "8": ["S", "Down"]}
+type Layout [16][]string
+
+func (l *Layout) UnmarshalJSON(b []byte) error {
+	var m map[string][]string
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+
+	*l = Layout{}
+
+	for digit, keys := range m {
+		key, err := strconv.ParseUint(digit, 16, 4)
+		if err != nil {
+			return fmt.Errorf("invalid CHIP-8 key %q, expected 0 to F", digit)
+		}
+
+		l[key] = keys
+	}
+
+	return nil
+}
+
+func (l Layout) MarshalJSON() ([]byte, error) {
+	m := make(map[string][]string)
+	for key, keys := range l {
+		if len(keys) > 0 {
+			m[fmt.Sprintf("%X", key)] = keys
+		}
+	}
+
+	return json.Marshal(m)
+}
+
+// Bindings returns the CHIP-8 key for each host key name, in lower case. It
+// is an error for a host key to press more than one CHIP-8 key.
+func (l Layout) Bindings() (map[string]uint8, error) {
+	bindings := make(map[string]uint8)
+
+	for key, names := range l {
+		for _, name := range names {
+			name = strings.ToLower(name)
+
+			if other, ok := bindings[name]; ok && other != uint8(key) {
+				return nil, fmt.Errorf("host key %q is bound to both %X and %X", name, other, key)
+			}
+
+			bindings[name] = uint8(key)
+		}
+	}
+
+	return bindings, nil
+}
+
+// layout builds a Layout from the host keys for the keypad rows 123C, 456D,
+// 789E and A0BF.
+func layout(rows ...string) Layout {
+	var l Layout
+
+	for i, keys := range [4][4]uint8{
+		{0x1, 0x2, 0x3, 0xC},
+		{0x4, 0x5, 0x6, 0xD},
+		{0x7, 0x8, 0x9, 0xE},
+		{0xA, 0x0, 0xB, 0xF},
+	} {
+		for j, name := range strings.Fields(rows[i]) {
+			l[keys[j]] = append(l[keys[j]], name)
+		}
+	}
+
+	return l
+}
+
+// with returns l with the extra host keys added.
+func with(l Layout, extra map[uint8]string) Layout {
+	for key, name := range extra {
+		l[key] = append(append([]string(nil), l[key]...), name)
+	}
+
+	return l
+}
+
+var qwerty = layout("1 2 3 4", "Q W E R", "A S D F", "Z X C V")
+
+// Layouts are the built-in layouts, each placing the keypad on the same
+// block of keys at the top left of the keyboard. arrows adds the arrow keys
+// to qwerty for games that move with 4, 5, 6 and 8.
+var Layouts = map[string]Layout{
+	"qwerty":  qwerty,
+	"qwertz":  layout("1 2 3 4", "Q W E R", "A S D F", "Y X C V"),
+	"azerty":  layout("1 2 3 4", "A Z E R", "Q S D F", "W X C V"),
+	"dvorak":  layout("1 2 3 4", "' , . P", "A O E U", "; Q J K"),
+	"colemak": layout("1 2 3 4", "Q W F P", "A R S T", "Z X C D"),
+	"arrows":  with(qwerty, map[uint8]string{0x5: "Up", 0x8: "Down", 0x4: "Left", 0x6: "Right"}),
+}
+
+// Names returns the names of the built-in layouts in sorted order.
+func Names() []string {
+	names := make([]string, 0, len(Layouts))
+	for name := range Layouts {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Config is the keymap configuration file:
+//
+//	{
+//	  "layout": "azerty",
+//	  "layouts": {"mine": {"5": ["Z", "Up"], ...}},
+//	  "roms": {"<sha1>": "arrows"}
+//	}
+type Config struct {
+	// Layout is the layout used for ROMs without one of their own.
+	Layout string `json:"layout"`
+	// Layouts add to or replace the built-in layouts.
+	Layouts map[string]Layout `json:"layouts"`
+	// ROMs maps the SHA-1 hash of a ROM to the name of its layout.
+	ROMs map[string]string `json:"roms"`
+}
+
+// Load reads the config in filename. A missing file gives an empty config.
+func Load(filename string) (*Config, error) {
+	var c Config
+
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &c, nil
+		}
+
+		return nil, fmt.Errorf("failed to read keymap: %v", err)
+	}
+
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("failed to decode keymap %s: %v", filename, err)
+	}
+
+	roms := make(map[string]string)
+	for hash, name := range c.ROMs {
+		roms[strings.ToLower(hash)] = name
+	}
+
+	c.ROMs = roms
+
+	return &c, nil
+}
+
+// Find returns the named layout, looking in the config before the built-in
+// layouts.
+func (c *Config) Find(name string) (Layout, error) {
+	if l, ok := c.Layouts[name]; ok {
+		return l, nil
+	}
+
+	if l, ok := Layouts[strings.ToLower(name)]; ok {
+		return l, nil
+	}
+
+	return Layout{}, fmt.Errorf("unknown keymap layout %q, expected one of %s or a layout in the keymap file", name, strings.Join(Names(), ", "))
+}
+
+// Select returns the layout for the ROM with the given hash: its own layout
+// if it has one, then the config's layout, then DefaultLayout.
+func (c *Config) Select(hash [sha1.Size]byte) (Layout, error) {
+	name := c.ROMs[hex.EncodeToString(hash[:])]
+
+	if name == "" {
+		name = c.Layout
+	}
+
+	if name == "" {
+		name = DefaultLayout
+	}
+
+	return c.Find(name)
+}
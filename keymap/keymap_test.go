@@ -0,0 +1,115 @@
+package keymap_test
+
+import (
+	"chip8/keymap"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type KeymapSuite struct {
+	suite.Suite
+
+	dir string
+}
+
+func (suite *KeymapSuite) SetupTest() {
+	var err error
+	suite.dir, err = ioutil.TempDir("", "keymap")
+	suite.Require().Nil(err)
+}
+
+func (suite *KeymapSuite) TearDownTest() {
+	os.RemoveAll(suite.dir)
+}
+
+func (suite *KeymapSuite) write(config string) *keymap.Config {
+	filename := filepath.Join(suite.dir, "keymap.json")
+	suite.Require().Nil(ioutil.WriteFile(filename, []byte(config), 0644))
+
+	c, err := keymap.Load(filename)
+	suite.Require().Nil(err)
+
+	return c
+}
+
+func (suite *KeymapSuite) TestBuiltIn() {
+	for _, name := range keymap.Names() {
+		bindings, err := keymap.Layouts[name].Bindings()
+		suite.Require().Nil(err, name)
+		suite.Assert().True(len(bindings) >= 16, name)
+	}
+
+	bindings, err := keymap.Layouts["azerty"].Bindings()
+	suite.Require().Nil(err)
+	suite.Assert().Equal(uint8(0x4), bindings["a"])
+	suite.Assert().Equal(uint8(0x7), bindings["q"])
+	suite.Assert().Equal(uint8(0xA), bindings["w"])
+
+	bindings, err = keymap.Layouts["arrows"].Bindings()
+	suite.Require().Nil(err)
+	suite.Assert().Equal(uint8(0x5), bindings["up"])
+	suite.Assert().Equal(uint8(0x5), bindings["w"])
+
+	// Adding the arrow keys leaves qwerty unchanged.
+	suite.Assert().Equal([]string{"W"}, keymap.Layouts["qwerty"][0x5])
+}
+
+func (suite *KeymapSuite) TestMissingFile() {
+	c, err := keymap.Load(filepath.Join(suite.dir, "missing.json"))
+	suite.Require().Nil(err)
+
+	l, err := c.Select(sha1.Sum(nil))
+	suite.Require().Nil(err)
+	suite.Assert().Equal(keymap.Layouts[keymap.DefaultLayout], l)
+}
+
+func (suite *KeymapSuite) TestSelect() {
+	rom := sha1.Sum([]byte{0x12, 0x00})
+
+	c := suite.write(`{
+		"layout": "dvorak",
+		"layouts": {"mine": {"5": ["I", "Keypad 8"], "a": ["Space"]}},
+		"roms": {"` + hex.EncodeToString(rom[:]) + `": "mine"}
+	}`)
+
+	l, err := c.Select(rom)
+	suite.Require().Nil(err)
+	suite.Assert().Equal([]string{"I", "Keypad 8"}, l[0x5])
+	suite.Assert().Equal([]string{"Space"}, l[0xA])
+
+	l, err = c.Select(sha1.Sum(nil))
+	suite.Require().Nil(err)
+	suite.Assert().Equal(keymap.Layouts["dvorak"], l)
+
+	_, err = c.Find("nope")
+	suite.Assert().Error(err)
+}
+
+func (suite *KeymapSuite) TestInvalid() {
+	var l keymap.Layout
+	suite.Assert().Error(json.Unmarshal([]byte(`{"G": ["Q"]}`), &l))
+
+	suite.Require().Nil(json.Unmarshal([]byte(`{"1": ["Q"], "2": ["q"]}`), &l))
+	_, err := l.Bindings()
+	suite.Assert().Error(err)
+}
+
+func (suite *KeymapSuite) TestMarshal() {
+	b, err := json.Marshal(keymap.Layouts["arrows"])
+	suite.Require().Nil(err)
+
+	var l keymap.Layout
+	suite.Require().Nil(json.Unmarshal(b, &l))
+	suite.Assert().Equal(keymap.Layouts["arrows"], l)
+}
+
+func TestKeymapSuite(t *testing.T) {
+	suite.Run(t, new(KeymapSuite))
+}
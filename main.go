@@ -1,15 +1,86 @@
 package main
 
 import (
+	"chip8/audio"
+	"chip8/chip8"
+	"chip8/database"
 	"chip8/emulator"
+	"chip8/frontend"
+	"chip8/keymap"
+	"chip8/tty"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 )
 
+var commands = map[string]func(args []string) error{
+	"asm":    runAsm,
+	"disasm": runDisasm,
+}
+
+// configFile returns the path of the named file in the user's config
+// directory.
+func configFile(name string) string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "chip8", name)
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command(os.Args[2:]); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+
+			return
+		}
+	}
+
+	quirks := flag.String("quirks", "", fmt.Sprintf("quirks profile (%s), defaults to the ROM's settings or legacy", strings.Join(chip8.QuirksProfiles(), ", ")))
+	ipf := flag.Int("ipf", 0, fmt.Sprintf("instructions executed per 60 Hz frame, defaults to the ROM's settings or %d", frontend.DefaultCyclesPerFrame))
+	screenshotDir := flag.String("screenshot-dir", ".", "directory F12 saves screenshots to")
+	screenshotScale := flag.Int("screenshot-scale", emulator.DefaultScreenshotScale, "size of a pixel in screenshots and screen recordings")
+	rewindDepth := flag.Int("rewind-depth", 600, "number of frames that can be rewound")
+	rewindBudget := flag.Int("rewind-budget", 16<<20, "memory budget for the rewind history in bytes")
+	loadAddress := flag.String("load-address", "", "address the ROM is loaded and started at (0x600 for ETI-660 programs), defaults to the ROM's settings or 0x200")
+	overrides := flag.String("overrides", configFile("overrides.json"), "file of per-ROM settings that replace the database")
+	layout := flag.String("keymap", "", fmt.Sprintf("keypad layout (%s or one from the keymap file), defaults to the ROM's layout in the keymap file or %s", strings.Join(keymap.Names(), ", "), keymap.DefaultLayout))
+	keymapFile := flag.String("keymap-file", configFile("keymap.json"), "file of keypad layouts and the layout to use for each ROM")
+	stickThreshold := flag.Float64("stick-threshold", emulator.DefaultStickThreshold, "how far, from 0 to 1, a controller stick or trigger must move to press a key")
+	toneFrequency := flag.Float64("tone-frequency", audio.DefaultOptions.Frequency, "pitch of the sound timer's tone in Hz")
+	waveform := flag.String("waveform", "sine", "waveform of the sound timer's tone (sine, square, triangle)")
+	volume := flag.Float64("volume", audio.DefaultOptions.Volume, "volume from 0 to 1")
+	recordAudio := flag.String("record-audio", "", "record the sound to this WAV file")
+	seed := flag.Int64("seed", 0, "seed for the random numbers of CXNN, 0 for a different seed every run")
+	vipRandom := flag.String("vip-random", "", "generate random numbers with the COSMAC VIP's routine; needs a 512-byte image of the VIP's CHIP-8 interpreter, which you must supply")
+	recordGIF := flag.String("record-gif", "", "record the screen to this animated GIF")
+	recordFrames := flag.String("record-frames", "", "write every frame to a numbered PNG in this directory")
+	recordMovie := flag.String("record-movie", "", "record the keypad input to this movie file")
+	playMovie := flag.String("play-movie", "", "replay the keypad input from this movie file")
+	frontendName := flag.String("frontend", "sdl", "frontend to run in: sdl for a window, or tty for the terminal")
+	keyRelease := flag.Duration("key-release", tty.DefaultKeyRelease, "how long a key stays down after the terminal last sends it (tty frontend); below the terminal's key repeat delay, held keys are released and pressed again before repeating starts")
+	bell := flag.Bool("bell", true, "ring the terminal bell when the sound starts; otherwise silent (tty frontend)")
+	debug := flag.Bool("debug", false, "start paused with a debugger on the terminal")
+
+	var tf traceFlags
+	flag.StringVar(&tf.filename, "trace", "", "write a trace of every instruction to this file (- for stdout)")
+	flag.StringVar(&tf.format, "trace-format", "text", "trace format (text, json)")
+	flag.StringVar(&tf.pcRange, "trace-range", "", "only trace instructions in this address range, e.g. 0x200-0x2FF")
+	flag.StringVar(&tf.ops, "trace-ops", "", "only trace these comma-separated mnemonics, e.g. DRW,CALL")
+
 	flag.Usage = func() {
-		fmt.Printf("Usage: %s [FILENAME]\n", os.Args[0])
+		fmt.Printf("Usage: %s [OPTIONS] [FILENAME]\n", os.Args[0])
+		fmt.Printf("       %s asm [OPTIONS] FILENAME\n", os.Args[0])
+		fmt.Printf("       %s disasm [OPTIONS] FILENAME\n", os.Args[0])
 		flag.PrintDefaults()
 	}
 	flag.Parse()
@@ -20,7 +91,204 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := emulator.Run(filename); err != nil {
-		panic(err)
+	db, err := database.New()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *overrides != "" {
+		if err := db.LoadOverrides(*overrides); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	km := &keymap.Config{}
+	if *keymapFile != "" {
+		km, err = keymap.Load(*keymapFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	options := emulator.Options{
+		Settings: frontend.Settings{
+			CyclesPerFrame: *ipf,
+			Database:       db,
+			Keymap:         km,
+			Layout:         *layout,
+			Seed:           *seed,
+		},
+		StickThreshold:  *stickThreshold,
+		ScreenshotDir:   *screenshotDir,
+		ScreenshotScale: *screenshotScale,
+		RewindDepth:     *rewindDepth,
+		RewindBudget:    *rewindBudget,
+		Debug:           *debug,
+	}
+
+	if *vipRandom != "" {
+		interpreter, err := ioutil.ReadFile(*vipRandom)
+		if err != nil {
+			fmt.Printf("failed to read VIP interpreter: %v\n", err)
+			os.Exit(1)
+		}
+
+		options.VIPInterpreter = interpreter
+	}
+
+	w, err := audio.ParseWaveform(*waveform)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *toneFrequency <= 0 || *volume < 0 || *volume > 1 {
+		fmt.Println("the tone frequency must be above 0 and the volume from 0 to 1")
+		os.Exit(1)
+	}
+
+	options.Audio = audio.Options{Frequency: *toneFrequency, Waveform: w, Volume: *volume}
+
+	if *screenshotScale < 1 {
+		fmt.Printf("invalid screenshot scale %d, expected at least 1\n", *screenshotScale)
+		os.Exit(1)
+	}
+
+	if *stickThreshold <= 0 || *stickThreshold > 1 {
+		fmt.Printf("invalid stick threshold %v, expected a value above 0 and at most 1\n", *stickThreshold)
+		os.Exit(1)
+	}
+
+	if *quirks != "" {
+		q, err := chip8.QuirksByName(*quirks)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		options.Quirks = &q
+	}
+
+	if *loadAddress != "" {
+		addr, err := strconv.ParseUint(*loadAddress, 0, 16)
+		if err != nil {
+			fmt.Printf("invalid load address %q: %v\n", *loadAddress, err)
+			os.Exit(1)
+		}
+
+		options.LoadAddress = uint16(addr)
+	}
+
+	if *frontendName != "sdl" && *frontendName != "tty" {
+		fmt.Printf("unknown frontend %q, expected sdl or tty\n", *frontendName)
+		os.Exit(1)
+	}
+
+	if *recordMovie != "" && *playMovie != "" {
+		fmt.Println("-record-movie and -play-movie cannot be used together")
+		os.Exit(1)
+	}
+
+	if *debug && (*recordMovie != "" || *playMovie != "") {
+		fmt.Println("-debug cannot be used while recording or playing a movie")
+		os.Exit(1)
+	}
+
+	if *recordGIF != "" && *recordFrames != "" {
+		fmt.Println("-record-gif and -record-frames cannot be used together")
+		os.Exit(1)
+	}
+
+	if *frontendName == "tty" {
+		if err := runTTY(filename, options, *keyRelease, *bell); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	closeTrace := func() error { return nil }
+	if tf.filename != "" {
+		t, c, err := openTrace(tf)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		options.Trace = t.Trace
+		closeTrace = c
+	}
+
+	closeRecording := func() error { return nil }
+	if *recordAudio != "" {
+		w, c, err := openAudioRecording(*recordAudio)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		options.RecordAudio = w
+		closeRecording = c
+	}
+
+	options.RecordFrames = *recordFrames
+
+	closeGIF := func() error { return nil }
+	if *recordGIF != "" {
+		f, err := os.Create(*recordGIF)
+		if err != nil {
+			fmt.Printf("failed to create GIF: %v\n", err)
+			os.Exit(1)
+		}
+
+		options.RecordGIF = f
+		closeGIF = f.Close
+	}
+
+	closeMovie := func() error { return nil }
+	if *recordMovie != "" {
+		f, err := os.Create(*recordMovie)
+		if err != nil {
+			fmt.Printf("failed to create movie: %v\n", err)
+			os.Exit(1)
+		}
+
+		options.RecordMovie = f
+		closeMovie = f.Close
+	} else if *playMovie != "" {
+		f, err := os.Open(*playMovie)
+		if err != nil {
+			fmt.Printf("failed to open movie: %v\n", err)
+			os.Exit(1)
+		}
+
+		options.PlayMovie = f
+		closeMovie = f.Close
+	}
+
+	err = emulator.Run(filename, options)
+	if traceErr := closeTrace(); err == nil {
+		err = traceErr
+	}
+
+	if recordErr := closeRecording(); err == nil {
+		err = recordErr
+	}
+
+	if movieErr := closeMovie(); err == nil {
+		err = movieErr
+	}
+
+	if gifErr := closeGIF(); err == nil {
+		err = gifErr
+	}
+
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,212 @@
+// Package movie records the input of a session so it can be replayed
+// exactly.
+//
+// A movie holds everything a run depends on besides the ROM: the quirks,
+// speed, load address, random seed and persistent flags, then the state of
+// the 16 keys for every frame. Replaying the frames into a machine set up
+// from the header repeats the original run instruction for instruction.
+package movie
+
+import (
+	"bufio"
+	"chip8/chip8"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const version = 2
+
+var magic = [4]byte{'C', '8', 'M', 'V'}
+
+var (
+	// ErrInvalidMovie is returned for data that isn't a movie.
+	ErrInvalidMovie = errors.New("not a chip8 movie")
+	// ErrVersion is returned for movies written by another version of the
+	// format.
+	ErrVersion = errors.New("unsupported movie version")
+	// ErrROMMismatch is returned by Header.Check for a different ROM.
+	ErrROMMismatch = errors.New("movie was recorded with a different ROM")
+	// ErrRandom is returned by Header.CheckRandom for a different random
+	// number routine.
+	ErrRandom = errors.New("movie was recorded with a different random number routine")
+)
+
+// Header describes the machine a movie was recorded on.
+type Header struct {
+	ROMHash        [sha1.Size]byte
+	Quirks         chip8.Quirks
+	Seed           int64
+	CyclesPerFrame int
+	LoadAddress    uint16
+	RPLFlags       [16]uint8
+	// VIPRandom is set if CXNN used chip8.VIPRandom.
+	VIPRandom bool
+}
+
+// Check returns ErrROMMismatch unless the movie was recorded with rom.
+func (h Header) Check(rom []byte) error {
+	if sha1.Sum(rom) != h.ROMHash {
+		return fmt.Errorf("%w: movie is for ROM %x", ErrROMMismatch, h.ROMHash)
+	}
+
+	return nil
+}
+
+// CheckRandom returns ErrRandom unless random is the kind of routine the movie
+// was recorded with.
+func (h Header) CheckRandom(random chip8.Random) error {
+	_, vip := random.(*chip8.VIPRandom)
+	if vip != h.VIPRandom {
+		return ErrRandom
+	}
+
+	return nil
+}
+
+type header struct {
+	Magic   [4]byte
+	Version uint16
+
+	ROMHash [sha1.Size]byte
+	Quirks  chip8.BinaryQuirks
+
+	Seed           int64
+	CyclesPerFrame uint32
+	LoadAddress    uint16
+	RPLFlags       [16]uint8
+	VIPRandom      bool
+}
+
+// Writer writes a movie one frame at a time. Frames are written straight to
+// the underlying writer so a movie is complete even if the session crashes.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter writes the header to w.
+func NewWriter(w io.Writer, h Header) (*Writer, error) {
+	mw := &Writer{w: w}
+
+	err := binary.Write(mw.w, binary.LittleEndian, &header{
+		Magic:   magic,
+		Version: version,
+
+		ROMHash: h.ROMHash,
+		Quirks:  h.Quirks.Binary(),
+
+		Seed:           h.Seed,
+		CyclesPerFrame: uint32(h.CyclesPerFrame),
+		LoadAddress:    h.LoadAddress,
+		RPLFlags:       h.RPLFlags,
+		VIPRandom:      h.VIPRandom,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write movie header: %v", err)
+	}
+
+	return mw, nil
+}
+
+// WriteFrame records the keys held during a frame.
+func (w *Writer) WriteFrame(keys [16]bool) error {
+	var bits uint16
+	for i, down := range keys {
+		if down {
+			bits |= 1 << i
+		}
+	}
+
+	if err := binary.Write(w.w, binary.LittleEndian, bits); err != nil {
+		return fmt.Errorf("failed to write movie frame: %v", err)
+	}
+
+	return nil
+}
+
+// Player reads a movie and plays it back through the chip8.Keys interface.
+type Player struct {
+	Header Header
+
+	r      *bufio.Reader
+	frames int
+
+	current  [16]bool
+	previous [16]bool
+}
+
+// NewPlayer reads the header from r.
+func NewPlayer(r io.Reader) (*Player, error) {
+	p := &Player{r: bufio.NewReader(r)}
+
+	var h header
+	if err := binary.Read(p.r, binary.LittleEndian, &h); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrInvalidMovie
+		}
+
+		return nil, fmt.Errorf("failed to read movie header: %v", err)
+	}
+
+	if h.Magic != magic {
+		return nil, ErrInvalidMovie
+	}
+
+	if h.Version != version {
+		return nil, fmt.Errorf("%w: %d", ErrVersion, h.Version)
+	}
+
+	p.Header = Header{
+		ROMHash:        h.ROMHash,
+		Quirks:         h.Quirks.Quirks(),
+		Seed:           h.Seed,
+		CyclesPerFrame: int(h.CyclesPerFrame),
+		LoadAddress:    h.LoadAddress,
+		RPLFlags:       h.RPLFlags,
+		VIPRandom:      h.VIPRandom,
+	}
+
+	return p, nil
+}
+
+// Next advances to the next frame's keys. It returns io.EOF after the last
+// frame.
+func (p *Player) Next() error {
+	var bits uint16
+	if err := binary.Read(p.r, binary.LittleEndian, &bits); err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.EOF
+		}
+
+		return fmt.Errorf("failed to read movie frame %d: %v", p.frames, err)
+	}
+
+	p.previous = p.current
+	for i := range p.current {
+		p.current[i] = bits&(1<<i) != 0
+	}
+
+	p.frames++
+
+	return nil
+}
+
+// Frames returns the number of frames played so far.
+func (p *Player) Frames() int {
+	return p.frames
+}
+
+// Keys returns the keys held in the current frame.
+func (p *Player) Keys() [16]bool {
+	return p.current
+}
+
+func (p *Player) IsKeyDown(i uint8) bool {
+	return p.current[i&0xF]
+}
+
+func (p *Player) WasKeyReleased(i uint8) bool {
+	return !p.current[i&0xF] && p.previous[i&0xF]
+}
@@ -0,0 +1,76 @@
+package movie_test
+
+import (
+	"bytes"
+	"chip8/chip8"
+	"chip8/movie"
+	"crypto/sha1"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MovieSuite struct {
+	suite.Suite
+}
+
+func (suite *MovieSuite) TestRoundTrip() {
+	rom := []byte{0x12, 0x00}
+
+	header := movie.Header{
+		ROMHash:        sha1.Sum(rom),
+		Quirks:         chip8.QuirksSuperChip,
+		Seed:           -42,
+		CyclesPerFrame: 30,
+		LoadAddress:    0x600,
+		RPLFlags:       [16]uint8{1, 2, 3},
+	}
+
+	var b bytes.Buffer
+	w, err := movie.NewWriter(&b, header)
+	suite.Require().Nil(err)
+
+	suite.Require().Nil(w.WriteFrame([16]bool{5: true}))
+	suite.Require().Nil(w.WriteFrame([16]bool{0: true, 15: true}))
+	suite.Require().Nil(w.WriteFrame([16]bool{}))
+
+	p, err := movie.NewPlayer(&b)
+	suite.Require().Nil(err)
+	suite.Assert().Equal(header, p.Header)
+	suite.Assert().Nil(p.Header.Check(rom))
+	suite.Assert().ErrorIs(p.Header.Check([]byte{0x00, 0xE0}), movie.ErrROMMismatch)
+
+	suite.Require().Nil(p.Next())
+	suite.Assert().True(p.IsKeyDown(5))
+	suite.Assert().False(p.IsKeyDown(0))
+
+	suite.Require().Nil(p.Next())
+	suite.Assert().Equal([16]bool{0: true, 15: true}, p.Keys())
+	suite.Assert().True(p.WasKeyReleased(5))
+
+	suite.Require().Nil(p.Next())
+	suite.Assert().True(p.WasKeyReleased(15))
+
+	suite.Assert().Equal(io.EOF, p.Next())
+	suite.Assert().Equal(3, p.Frames())
+}
+
+func (suite *MovieSuite) TestInvalid() {
+	_, err := movie.NewPlayer(bytes.NewReader([]byte("GIF89a")))
+	suite.Assert().ErrorIs(err, movie.ErrInvalidMovie)
+
+	var b bytes.Buffer
+	_, err = movie.NewWriter(&b, movie.Header{})
+	suite.Require().Nil(err)
+
+	header := b.Bytes()
+	header[4] = 99
+
+	_, err = movie.NewPlayer(bytes.NewReader(header))
+	suite.Assert().ErrorIs(err, movie.ErrVersion)
+}
+
+func TestMovieSuite(t *testing.T) {
+	suite.Run(t, new(MovieSuite))
+}
@@ -0,0 +1,216 @@
+// Package octo reads Octo cartridges: GIF images with a program and its Octo
+// options hidden in the low two bits of every pixel's palette index.
+//
+// The pixels of every frame, read in order, hold one byte per four pixels with
+// the first pixel in the most significant bits. The bytes are a 32-bit
+// big-endian length followed by that many bytes of JSON:
+//
+//	{"program": "...", "options": {"tickrate": 20, ...}}
+//
+// There is no Octo compiler here, so only cartridges holding a pre-assembled
+// program can be run: byte literals, as Octo writes when a binary is imported.
+package octo
+
+import (
+	"bytes"
+	"chip8/chip8"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"strconv"
+	"strings"
+)
+
+var gifMagic = [][]byte{[]byte("GIF87a"), []byte("GIF89a")}
+
+// ErrSource is returned by Cartridge.ROM for a program written in Octo source
+// rather than byte literals.
+var ErrSource = errors.New("the cartridge holds Octo source code, not a pre-assembled program")
+
+// IsCartridge reports whether b looks like a GIF, and so may be a cartridge.
+func IsCartridge(b []byte) bool {
+	for _, magic := range gifMagic {
+		if bytes.HasPrefix(b, magic) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Options are the Octo settings stored in a cartridge. Options the emulator
+// has no equivalent for are ignored.
+type Options struct {
+	Tickrate int `json:"tickrate"`
+
+	BackgroundColor string `json:"backgroundColor"`
+	FillColor       string `json:"fillColor"`
+	FillColor2      string `json:"fillColor2"`
+	BlendColor      string `json:"blendColor"`
+
+	ShiftQuirks     bool `json:"shiftQuirks"`
+	LoadStoreQuirks bool `json:"loadStoreQuirks"`
+	ClipQuirks      bool `json:"clipQuirks"`
+	JumpQuirks      bool `json:"jumpQuirks"`
+	LogicQuirks     bool `json:"logicQuirks"`
+	VBlankQuirks    bool `json:"vBlankQuirks"`
+
+	MaxSize int `json:"maxSize"`
+}
+
+// Octo's default colours, used for any the cartridge leaves out.
+var defaultColors = [4]string{"#996600", "#FFCC00", "#FF6600", "#662200"}
+
+// Quirks returns the quirks the options select. Octo always runs the
+// SUPER-CHIP instructions, and memory larger than a SUPER-CHIP's 3583 bytes of
+// program space enables XO-CHIP.
+func (o Options) Quirks() chip8.Quirks {
+	q := chip8.Quirks{
+		ShiftUsesVY:    !o.ShiftQuirks,
+		JumpUsesVX:     o.JumpQuirks,
+		IndexIncrement: chip8.IndexIncrementXPlusOne,
+		LogicResetsVF:  o.LogicQuirks,
+		WrapSprites:    !o.ClipQuirks,
+		DisplayWait:    o.VBlankQuirks,
+		SuperChip:      true,
+		XOChip:         o.MaxSize > 3583,
+	}
+
+	if o.LoadStoreQuirks {
+		q.IndexIncrement = chip8.IndexUnchanged
+	}
+
+	return q
+}
+
+// Palette returns the colours for pixel values 0 to 3: the background, the
+// first and second planes and both planes together.
+func (o Options) Palette() ([4]color.RGBA, error) {
+	var palette [4]color.RGBA
+
+	for i, s := range []string{o.BackgroundColor, o.FillColor, o.FillColor2, o.BlendColor} {
+		if s == "" {
+			s = defaultColors[i]
+		}
+
+		c, err := parseColor(s)
+		if err != nil {
+			return palette, err
+		}
+
+		palette[i] = c
+	}
+
+	return palette, nil
+}
+
+func parseColor(s string) (color.RGBA, error) {
+	if len(s) != 7 || s[0] != '#' {
+		return color.RGBA{}, fmt.Errorf("invalid colour %q, expected #RRGGBB", s)
+	}
+
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid colour %q, expected #RRGGBB", s)
+	}
+
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, nil
+}
+
+type Cartridge struct {
+	// Program is the Octo source of the program.
+	Program string  `json:"program"`
+	Options Options `json:"options"`
+}
+
+// Decode extracts the cartridge hidden in the GIF b.
+func Decode(b []byte) (*Cartridge, error) {
+	g, err := gif.DecodeAll(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode cartridge image: %v", err)
+	}
+
+	data := unpack(g.Image)
+
+	if len(data) < 4 {
+		return nil, fmt.Errorf("cartridge image holds no data")
+	}
+
+	size := binary.BigEndian.Uint32(data)
+	if uint64(size) > uint64(len(data)-4) {
+		return nil, fmt.Errorf("cartridge data is %d bytes, image only holds %d", size, len(data)-4)
+	}
+
+	var cart Cartridge
+	if err := json.Unmarshal(data[4:4+size], &cart); err != nil {
+		return nil, fmt.Errorf("failed to decode cartridge data: %v", err)
+	}
+
+	return &cart, nil
+}
+
+// unpack joins the low two bits of each pixel into bytes.
+func unpack(frames []*image.Paletted) []byte {
+	var (
+		data []byte
+		b    byte
+		n    int
+	)
+
+	for _, frame := range frames {
+		r := frame.Bounds()
+
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				b = b<<2 | frame.ColorIndexAt(x, y)&3
+				n++
+
+				if n == 4 {
+					data = append(data, b)
+					b, n = 0, 0
+				}
+			}
+		}
+	}
+
+	return data
+}
+
+// ROM returns the bytes of the program. Only programs made of byte literals,
+// as Octo writes when a binary is imported, can be loaded; anything else
+// fails with ErrSource.
+func (c *Cartridge) ROM() ([]byte, error) {
+	var rom []byte
+
+	for n, line := range strings.Split(c.Program, "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+
+		for i := 0; i < len(fields); i++ {
+			if fields[i] == ":" && i+1 < len(fields) && fields[i+1] == "main" && len(rom) == 0 {
+				i++
+				continue
+			}
+
+			v, err := strconv.ParseInt(fields[i], 0, 16)
+			if err != nil || v < -128 || v > 255 {
+				return nil, fmt.Errorf("%w (%q on line %d is not a byte literal)", ErrSource, fields[i], n+1)
+			}
+
+			rom = append(rom, byte(v))
+		}
+	}
+
+	if len(rom) == 0 {
+		return nil, fmt.Errorf("cartridge program is empty")
+	}
+
+	return rom, nil
+}
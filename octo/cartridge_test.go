@@ -0,0 +1,112 @@
+package octo_test
+
+import (
+	"bytes"
+	"chip8/chip8"
+	"chip8/octo"
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// cartridge encodes cart the way Octo does, spread over two 32x16 frames.
+func cartridge(cart octo.Cartridge) []byte {
+	payload, _ := json.Marshal(cart)
+
+	data := make([]byte, 4, 4+len(payload))
+	binary.BigEndian.PutUint32(data, uint32(len(payload)))
+	data = append(data, payload...)
+
+	palette := color.Palette{color.Black, color.White, color.Gray{0x55}, color.Gray{0xAA}}
+
+	var frames []*image.Paletted
+	for len(data) > 0 || len(frames) < 2 {
+		frame := image.NewPaletted(image.Rect(0, 0, 32, 16), palette)
+
+		for p := 0; p+3 < len(frame.Pix) && len(data) > 0; p += 4 {
+			for j := 0; j < 4; j++ {
+				frame.Pix[p+j] = data[0] >> (6 - 2*j) & 3
+			}
+
+			data = data[1:]
+		}
+
+		frames = append(frames, frame)
+	}
+
+	var b bytes.Buffer
+	_ = gif.EncodeAll(&b, &gif.GIF{Image: frames, Delay: make([]int, len(frames))})
+
+	return b.Bytes()
+}
+
+type CartridgeSuite struct {
+	suite.Suite
+}
+
+func (suite *CartridgeSuite) TestDecode() {
+	program := ": main\n  0x00 0xE0 # clear\n  0x12 0x02 18 0b1010\n"
+	for len(program) < 200 {
+		program += "0x00 "
+	}
+
+	b := cartridge(octo.Cartridge{Program: program, Options: octo.Options{Tickrate: 1000, FillColor: "#123456"}})
+	suite.Require().True(octo.IsCartridge(b))
+
+	cart, err := octo.Decode(b)
+	suite.Require().Nil(err)
+	suite.Assert().Equal(program, cart.Program)
+	suite.Assert().Equal(1000, cart.Options.Tickrate)
+
+	rom, err := cart.ROM()
+	suite.Require().Nil(err)
+	suite.Assert().Equal([]byte{0x00, 0xE0, 0x12, 0x02, 18, 10}, rom[:6])
+}
+
+func (suite *CartridgeSuite) TestOctoSource() {
+	cart := octo.Cartridge{Program: ": main\n  clear\n  loop again\n"}
+
+	_, err := cart.ROM()
+	suite.Assert().ErrorIs(err, octo.ErrSource)
+	suite.Assert().EqualError(err, `the cartridge holds Octo source code, not a pre-assembled program ("clear" on line 2 is not a byte literal)`)
+
+	// Labels other than a leading main are source too.
+	cart = octo.Cartridge{Program: ": main\n  0x00 0xE0\n: sprite\n  0xFF\n"}
+
+	_, err = cart.ROM()
+	suite.Assert().ErrorIs(err, octo.ErrSource)
+}
+
+func (suite *CartridgeSuite) TestNotCartridge() {
+	suite.Assert().False(octo.IsCartridge([]byte{0x00, 0xE0}))
+
+	_, err := octo.Decode([]byte("GIF89a"))
+	suite.Assert().Error(err)
+}
+
+func (suite *CartridgeSuite) TestQuirks() {
+	suite.Assert().Equal(chip8.QuirksXOChip, octo.Options{MaxSize: 65024}.Quirks())
+
+	q := octo.Options{ShiftQuirks: true, LoadStoreQuirks: true, ClipQuirks: true, JumpQuirks: true, MaxSize: 3583}.Quirks()
+	suite.Assert().Equal(chip8.QuirksSuperChip, q)
+}
+
+func (suite *CartridgeSuite) TestPalette() {
+	palette, err := octo.Options{BackgroundColor: "#000000", FillColor: "#FF8000"}.Palette()
+	suite.Require().Nil(err)
+	suite.Assert().Equal(color.RGBA{0, 0, 0, 255}, palette[0])
+	suite.Assert().Equal(color.RGBA{255, 128, 0, 255}, palette[1])
+	suite.Assert().Equal(color.RGBA{255, 102, 0, 255}, palette[2])
+
+	_, err = octo.Options{FillColor: "orange"}.Palette()
+	suite.Assert().Error(err)
+}
+
+func TestCartridgeSuite(t *testing.T) {
+	suite.Run(t, new(CartridgeSuite))
+}
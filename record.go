@@ -0,0 +1,35 @@
+package main
+
+import (
+	"chip8/audio"
+	"chip8/wav"
+	"fmt"
+	"io"
+	"os"
+)
+
+// openAudioRecording creates a WAV file for the emulator's sound. The
+// returned function finishes the file.
+func openAudioRecording(filename string) (io.Writer, func() error, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create audio recording: %v", err)
+	}
+
+	e, err := wav.NewEncoder(f, audio.DefaultSampleRate)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	closeRecording := func() error {
+		if err := e.Close(); err != nil {
+			f.Close()
+			return err
+		}
+
+		return f.Close()
+	}
+
+	return e, closeRecording, nil
+}
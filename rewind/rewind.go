@@ -0,0 +1,214 @@
+// Package rewind keeps a bounded history of machine states so that they can
+// be replayed backwards.
+//
+// Only the newest state is kept in full. Every older state is stored as the
+// XOR of itself with its successor, with runs of unchanged bytes run-length
+// encoded, so a frame that only touches a handful of registers costs a few
+// bytes rather than a whole copy of memory.
+package rewind
+
+import (
+	"encoding/binary"
+)
+
+type Buffer struct {
+	depth  int
+	budget int
+
+	latest []byte
+
+	// deltas is a ring of encoded deltas, oldest first. Applying the delta at
+	// the end of the ring to latest yields the state pushed before it.
+	deltas [][]byte
+	start  int
+	count  int
+
+	size int
+}
+
+// New creates a buffer holding at most depth states in at most budget bytes.
+// The newest state is always kept, even if it alone exceeds the budget.
+func New(depth, budget int) *Buffer {
+	if depth < 1 {
+		depth = 1
+	}
+
+	return &Buffer{
+		depth:  depth,
+		budget: budget,
+		deltas: make([][]byte, depth-1),
+	}
+}
+
+// Len returns the number of states that can be popped.
+func (b *Buffer) Len() int {
+	if b.latest == nil {
+		return 0
+	}
+
+	return b.count + 1
+}
+
+// Size returns the number of bytes used to hold the states.
+func (b *Buffer) Size() int {
+	return b.size
+}
+
+// Reset discards all states.
+func (b *Buffer) Reset() {
+	for i := range b.deltas {
+		b.deltas[i] = nil
+	}
+
+	b.latest = nil
+	b.start = 0
+	b.count = 0
+	b.size = 0
+}
+
+// Push records state as the newest state, evicting the oldest states if the
+// depth or memory budget is exceeded.
+func (b *Buffer) Push(state []byte) {
+	if b.latest != nil && len(b.deltas) > 0 {
+		delta := encode(b.latest, state)
+
+		if b.count == len(b.deltas) {
+			b.evict()
+		}
+
+		b.deltas[(b.start+b.count)%len(b.deltas)] = delta
+		b.count++
+		b.size += len(delta)
+	}
+
+	b.size += len(state) - len(b.latest)
+	b.latest = append(b.latest[:0], state...)
+
+	for b.count > 0 && b.size > b.budget {
+		b.evict()
+	}
+}
+
+// Peek returns the newest state without removing it.
+func (b *Buffer) Peek() ([]byte, bool) {
+	return b.latest, b.latest != nil
+}
+
+// Pop removes and returns the newest state. It returns false once the buffer
+// is empty.
+func (b *Buffer) Pop() ([]byte, bool) {
+	if b.latest == nil {
+		return nil, false
+	}
+
+	state := b.latest
+
+	if b.count == 0 {
+		b.latest = nil
+		b.size = 0
+
+		return state, true
+	}
+
+	last := (b.start + b.count - 1) % len(b.deltas)
+	delta := b.deltas[last]
+
+	b.deltas[last] = nil
+	b.count--
+	b.size -= len(delta) + len(state)
+
+	b.latest = decode(state, delta)
+	b.size += len(b.latest)
+
+	return state, true
+}
+
+func (b *Buffer) evict() {
+	b.size -= len(b.deltas[b.start])
+	b.deltas[b.start] = nil
+	b.start = (b.start + 1) % len(b.deltas)
+	b.count--
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+
+	return append(b, buf[:n]...)
+}
+
+// encode returns a delta that turns next back into previous. It holds the
+// length of previous followed by (zero run, literal length, literals) triples
+// describing previous XOR next.
+func encode(previous, next []byte) []byte {
+	n := len(previous)
+	if len(next) > n {
+		n = len(next)
+	}
+
+	delta := appendUvarint(nil, uint64(len(previous)))
+
+	at := func(b []byte, i int) byte {
+		if i < len(b) {
+			return b[i]
+		}
+
+		return 0
+	}
+
+	for i := 0; i < n; {
+		zeros := 0
+		for i < n && at(previous, i) == at(next, i) {
+			zeros++
+			i++
+		}
+
+		literals := 0
+		for i+literals < n && at(previous, i+literals) != at(next, i+literals) {
+			literals++
+		}
+
+		delta = appendUvarint(delta, uint64(zeros))
+		delta = appendUvarint(delta, uint64(literals))
+
+		for ; literals > 0; literals-- {
+			delta = append(delta, at(previous, i)^at(next, i))
+			i++
+		}
+	}
+
+	return delta
+}
+
+// decode applies a delta produced by encode to next, returning previous.
+func decode(next, delta []byte) []byte {
+	length, n := binary.Uvarint(delta)
+	delta = delta[n:]
+
+	size := int(length)
+	if len(next) > size {
+		size = len(next)
+	}
+
+	previous := make([]byte, size)
+	copy(previous, next)
+
+	i := 0
+	for len(delta) > 0 {
+		zeros, n := binary.Uvarint(delta)
+		delta = delta[n:]
+
+		literals, n := binary.Uvarint(delta)
+		delta = delta[n:]
+
+		i += int(zeros)
+		for j := 0; j < int(literals); j++ {
+			previous[i] ^= delta[j]
+			i++
+		}
+
+		delta = delta[literals:]
+	}
+
+	return previous[:length]
+}
@@ -0,0 +1,117 @@
+package rewind_test
+
+import (
+	"chip8/rewind"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func frame(n int) []byte {
+	state := make([]byte, 4096)
+	state[0] = byte(n)
+	state[100+n%8] = byte(n * 3)
+
+	return state
+}
+
+type BufferSuite struct {
+	suite.Suite
+}
+
+func (suite *BufferSuite) TestPopReturnsStatesInReverse() {
+	b := rewind.New(10, 1<<20)
+
+	for n := 0; n < 5; n++ {
+		b.Push(frame(n))
+	}
+
+	suite.Assert().Equal(5, b.Len())
+
+	for n := 4; n >= 0; n-- {
+		state, ok := b.Pop()
+		suite.Require().True(ok)
+		suite.Assert().Equal(frame(n), state)
+	}
+
+	_, ok := b.Pop()
+	suite.Assert().False(ok)
+	suite.Assert().Equal(0, b.Size())
+}
+
+func (suite *BufferSuite) TestDepth() {
+	b := rewind.New(3, 1<<20)
+
+	for n := 0; n < 10; n++ {
+		b.Push(frame(n))
+	}
+
+	suite.Assert().Equal(3, b.Len())
+
+	for n := 9; n >= 7; n-- {
+		state, ok := b.Pop()
+		suite.Require().True(ok)
+		suite.Assert().Equal(frame(n), state)
+	}
+}
+
+func (suite *BufferSuite) TestBudget() {
+	b := rewind.New(100, 4096+20)
+
+	for n := 0; n < 50; n++ {
+		b.Push(frame(n))
+	}
+
+	suite.Assert().LessOrEqual(b.Size(), 4096+20)
+	suite.Assert().Less(b.Len(), 50)
+
+	state, ok := b.Pop()
+	suite.Require().True(ok)
+	suite.Assert().Equal(frame(49), state)
+}
+
+func (suite *BufferSuite) TestDeltasAreSmall() {
+	b := rewind.New(100, 1<<20)
+
+	for n := 0; n < 50; n++ {
+		b.Push(frame(n))
+	}
+
+	suite.Assert().Less(b.Size(), 2*4096)
+}
+
+func (suite *BufferSuite) TestChangingLength() {
+	b := rewind.New(10, 1<<20)
+
+	b.Push([]byte{1, 2, 3})
+	b.Push([]byte{1, 2, 3, 4, 5})
+	b.Push([]byte{9})
+
+	for _, expected := range [][]byte{{9}, {1, 2, 3, 4, 5}, {1, 2, 3}} {
+		state, ok := b.Pop()
+		suite.Require().True(ok)
+		suite.Assert().Equal(expected, state)
+	}
+}
+
+func (suite *BufferSuite) TestPushAfterPop() {
+	b := rewind.New(10, 1<<20)
+
+	for n := 0; n < 5; n++ {
+		b.Push(frame(n))
+	}
+
+	_, _ = b.Pop()
+	_, _ = b.Pop()
+	b.Push(frame(42))
+
+	for _, n := range []int{42, 2, 1, 0} {
+		state, ok := b.Pop()
+		suite.Require().True(ok)
+		suite.Assert().Equal(frame(n), state)
+	}
+}
+
+func TestBuffer(t *testing.T) {
+	suite.Run(t, new(BufferSuite))
+}
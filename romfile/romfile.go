@@ -0,0 +1,212 @@
+// Package romfile reads ROMs from raw files, .zip and .gz archives and Octo
+// cartridges. There is no Octo compiler, so only cartridges holding a
+// pre-assembled program can be read; one holding Octo source fails with an
+// error, wrapping octo.ErrSource, that tells the user to export a binary from
+// Octo instead.
+package romfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"chip8/database"
+	"chip8/octo"
+	"compress/gzip"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+// MaxSize is the largest ROM that can be read, the size of XO-CHIP memory.
+// It also bounds how much an archive is allowed to decompress to.
+const MaxSize = 65536
+
+// Extensions are the file extensions recognised as ROMs inside a zip archive.
+var Extensions = []string{".ch8", ".c8", ".sc8", ".xo8", ".c8x", ".rom"}
+
+var (
+	gzipMagic = []byte{0x1F, 0x8B}
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+// Load reads the ROM in filename.
+func Load(filename string) ([]byte, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ROM file: %v", err)
+	}
+
+	rom, err := Decode(b)
+	if err != nil {
+		return nil, explainSource(filename, err)
+	}
+
+	return rom, nil
+}
+
+// explainSource adds what to do instead to the error for a cartridge of Octo
+// source.
+func explainSource(filename string, err error) error {
+	if !errors.Is(err, octo.ErrSource) {
+		return err
+	}
+
+	return fmt.Errorf("cannot load %s: %w; there is no Octo compiler here, so open the cartridge in Octo, export the program as a .ch8 binary and load that instead", filename, err)
+}
+
+// LoadWithSettings reads the ROM in filename and its settings: the options
+// stored in an Octo cartridge holding a pre-assembled program, or its entry
+// in db if db is not nil. The settings are nil if there are none.
+func LoadWithSettings(filename string, db *database.Database) ([]byte, *database.Entry, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read ROM file: %v", err)
+	}
+
+	if octo.IsCartridge(b) {
+		cart, err := octo.Decode(b)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		palette, err := cart.Options.Palette()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read cartridge options: %v", err)
+		}
+
+		rom, err := cart.ROM()
+		if err != nil {
+			return nil, nil, explainSource(filename, err)
+		}
+
+		return rom, &database.Entry{
+			Quirks:   cart.Options.Quirks(),
+			Tickrate: cart.Options.Tickrate,
+			Palette:  palette[:],
+		}, nil
+	}
+
+	rom, err := Decode(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if db == nil {
+		return rom, nil, nil
+	}
+
+	settings, err := db.Lookup(sha1.Sum(rom))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to look up ROM: %v", err)
+	}
+
+	return rom, settings, nil
+}
+
+// Decode returns the ROM held in b. Archives and cartridges are recognised by
+// their contents rather than their name; anything else is returned unchanged.
+func Decode(b []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(b, gzipMagic):
+		return decodeGzip(b)
+	case bytes.HasPrefix(b, zipMagic):
+		return decodeZip(b)
+	case octo.IsCartridge(b):
+		cart, err := octo.Decode(b)
+		if err != nil {
+			return nil, err
+		}
+
+		return cart.ROM()
+	}
+
+	return b, nil
+}
+
+func readLimited(r io.Reader) ([]byte, error) {
+	b, err := ioutil.ReadAll(io.LimitReader(r, MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) > MaxSize {
+		return nil, fmt.Errorf("ROM is larger than %d bytes", MaxSize)
+	}
+
+	return b, nil
+}
+
+func decodeGzip(b []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open gzip archive: %v", err)
+	}
+	defer r.Close()
+
+	rom, err := readLimited(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress gzip archive: %v", err)
+	}
+
+	return rom, nil
+}
+
+func isROM(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+
+	for _, e := range Extensions {
+		if ext == e {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decodeZip returns the only file in the archive, or the only file with a ROM
+// extension if there are several.
+func decodeZip(b []byte) ([]byte, error) {
+	r, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip archive: %v", err)
+	}
+
+	var files, roms []*zip.File
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		files = append(files, f)
+		if isROM(f.Name) {
+			roms = append(roms, f)
+		}
+	}
+
+	if len(files) == 1 {
+		roms = files
+	}
+
+	switch {
+	case len(roms) == 0:
+		return nil, fmt.Errorf("zip archive contains no ROM")
+	case len(roms) > 1:
+		return nil, fmt.Errorf("zip archive contains %d ROMs, expected one", len(roms))
+	}
+
+	f, err := roms[0].Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s in zip archive: %v", roms[0].Name, err)
+	}
+	defer f.Close()
+
+	rom, err := readLimited(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress %s in zip archive: %v", roms[0].Name, err)
+	}
+
+	return rom, nil
+}
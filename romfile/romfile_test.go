@@ -0,0 +1,173 @@
+package romfile_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"chip8/database"
+	"chip8/octo"
+	"chip8/romfile"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/gif"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+var rom = []byte{0x00, 0xE0, 0x12, 0x02}
+
+func zipped(files map[string][]byte) []byte {
+	var b bytes.Buffer
+
+	w := zip.NewWriter(&b)
+	for name, data := range files {
+		f, _ := w.Create(name)
+		_, _ = f.Write(data)
+	}
+	_ = w.Close()
+
+	return b.Bytes()
+}
+
+// cartridge encodes cart as an Octo cartridge in a single frame, 32 pixels
+// wide.
+func cartridge(cart octo.Cartridge) []byte {
+	payload, _ := json.Marshal(cart)
+
+	data := make([]byte, 4, 4+len(payload))
+	binary.BigEndian.PutUint32(data, uint32(len(payload)))
+	data = append(data, payload...)
+
+	palette := color.Palette{color.Black, color.White, color.Gray{0x55}, color.Gray{0xAA}}
+	frame := image.NewPaletted(image.Rect(0, 0, 32, (len(data)+7)/8), palette)
+	for i, c := range data {
+		for j := 0; j < 4; j++ {
+			frame.Pix[4*i+j] = c >> (6 - 2*j) & 3
+		}
+	}
+
+	var b bytes.Buffer
+	_ = gif.EncodeAll(&b, &gif.GIF{Image: []*image.Paletted{frame}, Delay: []int{0}})
+
+	return b.Bytes()
+}
+
+type RomfileSuite struct {
+	suite.Suite
+}
+
+func (suite *RomfileSuite) TestRaw() {
+	b, err := romfile.Decode(rom)
+	suite.Require().Nil(err)
+	suite.Assert().Equal(rom, b)
+}
+
+func (suite *RomfileSuite) TestGzip() {
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	_, _ = w.Write(rom)
+	suite.Require().Nil(w.Close())
+
+	b, err := romfile.Decode(gz.Bytes())
+	suite.Require().Nil(err)
+	suite.Assert().Equal(rom, b)
+}
+
+func (suite *RomfileSuite) TestZip() {
+	b, err := romfile.Decode(zipped(map[string][]byte{"game.bin": rom}))
+	suite.Require().Nil(err)
+	suite.Assert().Equal(rom, b)
+
+	b, err = romfile.Decode(zipped(map[string][]byte{"README.txt": []byte("hello"), "games/Game.CH8": rom}))
+	suite.Require().Nil(err)
+	suite.Assert().Equal(rom, b)
+}
+
+func (suite *RomfileSuite) TestZipAmbiguous() {
+	_, err := romfile.Decode(zipped(map[string][]byte{"a.ch8": rom, "b.ch8": rom}))
+	suite.Assert().Error(err)
+
+	_, err = romfile.Decode(zipped(map[string][]byte{"a.txt": rom, "b.txt": rom}))
+	suite.Assert().Error(err)
+}
+
+func (suite *RomfileSuite) TestTooLarge() {
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	_, _ = w.Write(make([]byte, romfile.MaxSize+1))
+	suite.Require().Nil(w.Close())
+
+	_, err := romfile.Decode(gz.Bytes())
+	suite.Assert().Error(err)
+}
+
+func (suite *RomfileSuite) TestLoad() {
+	dir, err := ioutil.TempDir("", "romfile")
+	suite.Require().Nil(err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "game.zip")
+	suite.Require().Nil(ioutil.WriteFile(filename, zipped(map[string][]byte{"game.ch8": rom}), 0644))
+
+	b, err := romfile.Load(filename)
+	suite.Require().Nil(err)
+	suite.Assert().Equal(rom, b)
+}
+
+func (suite *RomfileSuite) TestLoadWithSettings() {
+	dir, err := ioutil.TempDir("", "romfile")
+	suite.Require().Nil(err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "game.ch8")
+	suite.Require().Nil(ioutil.WriteFile(filename, rom, 0644))
+
+	b, settings, err := romfile.LoadWithSettings(filename, nil)
+	suite.Require().Nil(err)
+	suite.Assert().Equal(rom, b)
+	suite.Assert().Nil(settings)
+
+	hash := sha1.Sum(rom)
+	overrides := filepath.Join(dir, "overrides.json")
+	suite.Require().Nil(ioutil.WriteFile(overrides, []byte(`{"`+hex.EncodeToString(hash[:])+`": {"title": "Game", "tickrate": 20}}`), 0644))
+
+	db, err := database.New()
+	suite.Require().Nil(err)
+	suite.Require().Nil(db.LoadOverrides(overrides))
+
+	_, settings, err = romfile.LoadWithSettings(filename, db)
+	suite.Require().Nil(err)
+	suite.Require().NotNil(settings)
+	suite.Assert().Equal("Game", settings.Title)
+	suite.Assert().Equal(20, settings.Tickrate)
+}
+
+func (suite *RomfileSuite) TestOctoSource() {
+	dir, err := ioutil.TempDir("", "romfile")
+	suite.Require().Nil(err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "game.gif")
+	suite.Require().Nil(ioutil.WriteFile(filename, cartridge(octo.Cartridge{Program: ": main\n\tclear\n"}), 0644))
+
+	_, _, err = romfile.LoadWithSettings(filename, nil)
+	suite.Require().Error(err)
+	suite.Assert().ErrorIs(err, octo.ErrSource)
+	suite.Assert().Contains(err.Error(), filename)
+	suite.Assert().Contains(err.Error(), "export the program as a .ch8 binary")
+
+	_, err = romfile.Load(filename)
+	suite.Assert().ErrorIs(err, octo.ErrSource)
+}
+
+func TestRomfileSuite(t *testing.T) {
+	suite.Run(t, new(RomfileSuite))
+}
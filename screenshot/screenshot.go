@@ -0,0 +1,102 @@
+// Package screenshot encodes a CHIP-8 framebuffer as a PNG image.
+package screenshot
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultPalette draws black pixels on a white background.
+var DefaultPalette = [4]color.RGBA{
+	{255, 255, 255, 255},
+	{0, 0, 0, 255},
+	{255, 102, 0, 255},
+	{102, 34, 0, 255},
+}
+
+type Options struct {
+	// Scale is the width and height in the image of each CHIP-8 pixel. Zero
+	// means 1.
+	Scale int
+	// Palette holds the colours of pixel values 0 to 3: the background, the
+	// foreground, and the XO-CHIP second plane and overlap. It defaults to
+	// DefaultPalette.
+	Palette *[4]color.RGBA
+}
+
+// Image renders pixels, as passed to a display.Drawer, to an image.
+func Image(pixels [][]uint8, options Options) *image.RGBA {
+	scale := options.Scale
+	if scale == 0 {
+		scale = 1
+	}
+
+	palette := DefaultPalette
+	if options.Palette != nil {
+		palette = *options.Palette
+	}
+
+	height := len(pixels)
+	width := 0
+	if height > 0 {
+		width = len(pixels[0])
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width*scale, height*scale))
+
+	for y := range pixels {
+		for x, p := range pixels[y] {
+			c := palette[p&3]
+
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetRGBA(x*scale+dx, y*scale+dy, c)
+				}
+			}
+		}
+	}
+
+	return img
+}
+
+// Encode writes pixels to w as a PNG.
+func Encode(w io.Writer, pixels [][]uint8, options Options) error {
+	if err := png.Encode(w, Image(pixels, options)); err != nil {
+		return fmt.Errorf("failed to encode screenshot: %v", err)
+	}
+
+	return nil
+}
+
+// Filename names a screenshot of rom taken at t, after the ROM's file name
+// without its extension, e.g. "pong-20240131-154502.123.png".
+func Filename(rom string, t time.Time) string {
+	name := filepath.Base(rom)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+
+	return fmt.Sprintf("%s-%s.png", name, t.Format("20060102-150405.000"))
+}
+
+// Save writes pixels to a PNG in dir named by Filename, and returns its path.
+func Save(dir, rom string, pixels [][]uint8, options Options) (string, error) {
+	path := filepath.Join(dir, Filename(rom, time.Now()))
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create screenshot: %v", err)
+	}
+
+	if err := Encode(f, pixels, options); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+
+	return path, f.Close()
+}
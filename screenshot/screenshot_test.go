@@ -0,0 +1,64 @@
+package screenshot_test
+
+import (
+	"bytes"
+	"chip8/screenshot"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ScreenshotSuite struct {
+	suite.Suite
+}
+
+func (suite *ScreenshotSuite) TestImage() {
+	pixels := [][]uint8{
+		{0, 1, 2},
+		{3, 0, 1},
+	}
+
+	palette := [4]color.RGBA{
+		{0, 0, 0, 255},
+		{255, 255, 255, 255},
+		{255, 0, 0, 255},
+		{0, 0, 255, 255},
+	}
+
+	img := screenshot.Image(pixels, screenshot.Options{Scale: 2, Palette: &palette})
+	suite.Assert().Equal(6, img.Bounds().Dx())
+	suite.Assert().Equal(4, img.Bounds().Dy())
+
+	suite.Assert().Equal(palette[0], img.RGBAAt(1, 1))
+	suite.Assert().Equal(palette[1], img.RGBAAt(2, 0))
+	suite.Assert().Equal(palette[1], img.RGBAAt(3, 1))
+	suite.Assert().Equal(palette[2], img.RGBAAt(5, 0))
+	suite.Assert().Equal(palette[3], img.RGBAAt(0, 3))
+}
+
+func (suite *ScreenshotSuite) TestEncode() {
+	pixels := [][]uint8{{0, 1}}
+
+	var b bytes.Buffer
+	suite.Require().Nil(screenshot.Encode(&b, pixels, screenshot.Options{}))
+
+	img, err := png.Decode(&b)
+	suite.Require().Nil(err)
+	suite.Assert().Equal(2, img.Bounds().Dx())
+	suite.Assert().Equal(1, img.Bounds().Dy())
+
+	r, g, b2, _ := img.At(1, 0).RGBA()
+	suite.Assert().Equal([]uint32{0, 0, 0}, []uint32{r, g, b2})
+}
+
+func (suite *ScreenshotSuite) TestFilename() {
+	t := time.Date(2024, 1, 31, 15, 45, 2, 123e6, time.UTC)
+	suite.Assert().Equal("pong-20240131-154502.123.png", screenshot.Filename("roms/pong.ch8", t))
+}
+
+func TestScreenshotSuite(t *testing.T) {
+	suite.Run(t, new(ScreenshotSuite))
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"chip8/trace"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type traceFlags struct {
+	filename string
+	format   string
+	pcRange  string
+	ops      string
+}
+
+func parseTraceRange(s string) (uint16, uint16, error) {
+	parts := strings.SplitN(s, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid trace range %q, expected START-END", s)
+	}
+
+	start, err := strconv.ParseUint(parts[0], 0, 16)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid trace range %q: %v", s, err)
+	}
+
+	end, err := strconv.ParseUint(parts[1], 0, 16)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid trace range %q: %v", s, err)
+	}
+
+	return uint16(start), uint16(end), nil
+}
+
+// openTrace creates the tracer described by the flags. The returned function
+// flushes and closes the trace output.
+func openTrace(flags traceFlags) (*trace.Tracer, func() error, error) {
+	format, err := trace.ParseFormat(flags.format)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	options := trace.Options{Format: format}
+
+	if flags.pcRange != "" {
+		if options.Start, options.End, err = parseTraceRange(flags.pcRange); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if flags.ops != "" {
+		options.Mnemonics = strings.Split(flags.ops, ",")
+	}
+
+	var out io.WriteCloser = os.Stdout
+	if flags.filename != "-" {
+		if out, err = os.Create(flags.filename); err != nil {
+			return nil, nil, fmt.Errorf("failed to create trace file: %v", err)
+		}
+	}
+
+	w := bufio.NewWriter(out)
+	t := trace.New(w, options)
+
+	closeTrace := func() error {
+		if err := t.Err(); err != nil {
+			return fmt.Errorf("failed to write trace: %v", err)
+		}
+
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("failed to write trace: %v", err)
+		}
+
+		if out != os.Stdout {
+			return out.Close()
+		}
+
+		return nil
+	}
+
+	return t, closeTrace, nil
+}
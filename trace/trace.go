@@ -0,0 +1,180 @@
+// Package trace writes a log of every instruction a Chip8 executes.
+//
+// Each line holds the cycle number, PC, raw opcode, the instruction in the
+// syntax accepted by the asm package, I, SP and any registers or timers the
+// instruction changed. Nothing in the output depends on wall-clock time, so
+// traces of two runs of the same ROM can be compared with diff.
+//
+//	00000042 0204 F033 LD B, V0             I=300 SP=0
+//	00000043 0206 F265 LD V2, [I]           I=300 SP=0 V0=01 V1=02 V2=03
+package trace
+
+import (
+	"chip8/chip8"
+	"chip8/disasm"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type Format int
+
+const (
+	FormatText Format = iota
+	// FormatJSON writes one JSON object per line.
+	FormatJSON
+)
+
+var formats = map[string]Format{
+	"text": FormatText,
+	"json": FormatJSON,
+}
+
+func ParseFormat(name string) (Format, error) {
+	f, ok := formats[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown trace format %q, expected text or json", name)
+	}
+
+	return f, nil
+}
+
+type Options struct {
+	Format Format
+
+	// Start and End limit the trace to instructions at addresses Start to End
+	// inclusive. An End of zero means no upper limit.
+	Start uint16
+	End   uint16
+
+	// Mnemonics limits the trace to instructions with these mnemonics, such
+	// as DRW or CALL. All instructions are traced if it is empty.
+	Mnemonics []string
+}
+
+// Tracer formats the steps passed to Trace. Install it with
+// Chip8.SetTraceHook(t.Trace).
+type Tracer struct {
+	w         io.Writer
+	options   Options
+	mnemonics map[string]bool
+
+	cycle uint64
+	err   error
+}
+
+func New(w io.Writer, options Options) *Tracer {
+	t := &Tracer{w: w, options: options}
+
+	if len(options.Mnemonics) > 0 {
+		t.mnemonics = make(map[string]bool)
+		for _, m := range options.Mnemonics {
+			t.mnemonics[strings.ToUpper(m)] = true
+		}
+	}
+
+	return t
+}
+
+// Err returns the first error writing the trace. Nothing more is written
+// after an error.
+func (t *Tracer) Err() error {
+	return t.err
+}
+
+type change struct {
+	name  string
+	value int
+}
+
+type record struct {
+	Cycle       uint64         `json:"cycle"`
+	PC          uint16         `json:"pc"`
+	Opcode      string         `json:"opcode"`
+	Instruction string         `json:"instruction"`
+	I           uint16         `json:"i"`
+	SP          uint16         `json:"sp"`
+	Changes     map[string]int `json:"changes,omitempty"`
+}
+
+func (t *Tracer) Trace(step chip8.Step) {
+	cycle := t.cycle
+	t.cycle++
+
+	if t.err != nil || step.PC < t.options.Start || (t.options.End != 0 && step.PC > t.options.End) {
+		return
+	}
+
+	text, ok := disasm.Format(step.Opcode, step.Long, disasm.SyntaxCowgod)
+	if !ok {
+		text = "???"
+	}
+
+	if t.mnemonics != nil && !t.mnemonics[strings.Fields(text)[0]] {
+		return
+	}
+
+	changes := diff(step.Before, step.After)
+
+	if t.options.Format == FormatJSON {
+		r := record{
+			Cycle:       cycle,
+			PC:          step.PC,
+			Opcode:      fmt.Sprintf("%04X", uint16(step.Opcode)),
+			Instruction: text,
+			I:           step.After.I,
+			SP:          step.After.SP,
+		}
+
+		if len(changes) > 0 {
+			r.Changes = make(map[string]int)
+			for _, c := range changes {
+				r.Changes[c.name] = c.value
+			}
+		}
+
+		b, err := json.Marshal(r)
+		if err != nil {
+			t.err = fmt.Errorf("failed to encode trace: %v", err)
+			return
+		}
+
+		_, t.err = t.w.Write(append(b, '\n'))
+
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%08d %04X %04X %-20s I=%03X SP=%X", cycle, step.PC, uint16(step.Opcode), text, step.After.I, step.After.SP)
+
+	for _, c := range changes {
+		fmt.Fprintf(&b, " %s=%02X", c.name, c.value)
+	}
+
+	b.WriteByte('\n')
+
+	_, t.err = io.WriteString(t.w, b.String())
+}
+
+// diff returns the registers and timers that differ between before and
+// after, in a fixed order. I and SP are always written so are left out.
+func diff(before, after chip8.Registers) []change {
+	var changes []change
+
+	for i := range after.V {
+		if before.V[i] != after.V[i] {
+			changes = append(changes, change{fmt.Sprintf("V%X", i), int(after.V[i])})
+		}
+	}
+
+	if before.DelayTimer != after.DelayTimer {
+		changes = append(changes, change{"DT", int(after.DelayTimer)})
+	}
+
+	if before.SoundTimer != after.SoundTimer {
+		changes = append(changes, change{"ST", int(after.SoundTimer)})
+	}
+
+	return changes
+}
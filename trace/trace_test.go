@@ -0,0 +1,89 @@
+package trace_test
+
+import (
+	"bytes"
+	"chip8/headless"
+	"chip8/trace"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+var program = []byte{
+	0x60, 0x7B, // 200: LD V0, #7B
+	0xA3, 0x00, // 202: LD I, #300
+	0x22, 0x08, // 204: CALL 208
+	0x12, 0x06, // 206: JP 206
+	0xF0, 0x15, // 208: LD DT, V0
+	0x00, 0xEE, // 20A: RET
+}
+
+type TraceSuite struct {
+	suite.Suite
+}
+
+func (suite *TraceSuite) trace(options trace.Options, cycles int) string {
+	r, err := headless.New(bytes.NewReader(program), headless.Options{})
+	suite.Require().Nil(err)
+
+	var out bytes.Buffer
+	t := trace.New(&out, options)
+	r.Chip8.SetTraceHook(t.Trace)
+
+	for i := 0; i < cycles; i++ {
+		suite.Require().Nil(r.Chip8.Cycle())
+	}
+
+	suite.Require().Nil(t.Err())
+
+	return out.String()
+}
+
+func (suite *TraceSuite) TestText() {
+	expected := "" +
+		"00000000 0200 607B LD V0, #7B           I=000 SP=0 V0=7B\n" +
+		"00000001 0202 A300 LD I, #300           I=300 SP=0\n" +
+		"00000002 0204 2208 CALL #208            I=300 SP=1\n" +
+		"00000003 0208 F015 LD DT, V0            I=300 SP=1 DT=7B\n" +
+		"00000004 020A 00EE RET                  I=300 SP=0\n" +
+		"00000005 0206 1206 JP #206              I=300 SP=0\n"
+
+	suite.Assert().Equal(expected, suite.trace(trace.Options{}, 6))
+}
+
+func (suite *TraceSuite) TestJSON() {
+	lines := strings.Split(suite.trace(trace.Options{Format: trace.FormatJSON}, 4), "\n")
+
+	suite.Assert().Equal(`{"cycle":0,"pc":512,"opcode":"607B","instruction":"LD V0, #7B","i":0,"sp":0,"changes":{"V0":123}}`, lines[0])
+	suite.Assert().Equal(`{"cycle":1,"pc":514,"opcode":"A300","instruction":"LD I, #300","i":768,"sp":0}`, lines[1])
+	suite.Assert().Equal(`{"cycle":3,"pc":520,"opcode":"F015","instruction":"LD DT, V0","i":768,"sp":1,"changes":{"DT":123}}`, lines[3])
+}
+
+func (suite *TraceSuite) TestRange() {
+	out := suite.trace(trace.Options{Start: 0x204, End: 0x208}, 6)
+
+	suite.Assert().Equal(3, strings.Count(out, "\n"))
+	suite.Assert().True(strings.HasPrefix(out, "00000002 0204"))
+}
+
+func (suite *TraceSuite) TestMnemonics() {
+	out := suite.trace(trace.Options{Mnemonics: []string{"call", "RET"}}, 6)
+
+	suite.Assert().Equal(2, strings.Count(out, "\n"))
+	suite.Assert().Contains(out, "CALL #208")
+	suite.Assert().Contains(out, "RET")
+}
+
+func (suite *TraceSuite) TestParseFormat() {
+	f, err := trace.ParseFormat("JSON")
+	suite.Require().Nil(err)
+	suite.Assert().Equal(trace.FormatJSON, f)
+
+	_, err = trace.ParseFormat("xml")
+	suite.Assert().Error(err)
+}
+
+func TestTraceSuite(t *testing.T) {
+	suite.Run(t, new(TraceSuite))
+}
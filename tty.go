@@ -0,0 +1,57 @@
+package main
+
+import (
+	"chip8/emulator"
+	"chip8/tty"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// sdlOnlyFlags are the flags the terminal frontend doesn't support.
+var sdlOnlyFlags = map[string]bool{
+	"screenshot-dir":   true,
+	"screenshot-scale": true,
+	"rewind-depth":     true,
+	"rewind-budget":    true,
+	"stick-threshold":  true,
+	"tone-frequency":   true,
+	"waveform":         true,
+	"volume":           true,
+	"debug":            true,
+	"trace":            true,
+	"trace-format":     true,
+	"trace-range":      true,
+	"trace-ops":        true,
+	"record-audio":     true,
+	"record-gif":       true,
+	"record-frames":    true,
+	"record-movie":     true,
+	"play-movie":       true,
+	"vip-random":       true,
+}
+
+// runTTY runs the emulator in the terminal with the options that apply to it.
+func runTTY(filename string, options emulator.Options, keyRelease time.Duration, bell bool) error {
+	var unsupported []string
+	flag.Visit(func(f *flag.Flag) {
+		if sdlOnlyFlags[f.Name] {
+			unsupported = append(unsupported, "-"+f.Name)
+		}
+	})
+
+	if len(unsupported) > 0 {
+		return fmt.Errorf("%s cannot be used with -frontend=tty", strings.Join(unsupported, ", "))
+	}
+
+	if keyRelease <= 0 {
+		return fmt.Errorf("invalid key release timeout %v, expected a duration above 0", keyRelease)
+	}
+
+	return tty.Run(filename, tty.Options{
+		Settings:   options.Settings,
+		KeyRelease: keyRelease,
+		Bell:       bell,
+	})
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package tty
+
+import "errors"
+
+func makeRaw(fd uintptr) (func() error, error) {
+	return nil, errors.New("the terminal frontend is not supported on this platform")
+}
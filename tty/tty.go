@@ -0,0 +1,394 @@
+// Package tty runs a Chip8 in a terminal, for machines without a graphical
+// session. The screen is drawn with Unicode half blocks, two pixels to a
+// character cell, and the keypad is read from stdin in raw mode.
+package tty
+
+import (
+	"bufio"
+	"bytes"
+	"chip8/chip8"
+	"chip8/database"
+	"chip8/frontend"
+	"chip8/keymap"
+	"chip8/romfile"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	framesPerSecond = 60
+
+	// DefaultKeyRelease outlasts the pause, usually 500 to 660ms, that
+	// terminals make before they start repeating a held key, so a held key
+	// isn't released and pressed again during it. A tap stays down as long.
+	DefaultKeyRelease = 700 * time.Millisecond
+
+	// quitInput ends the session. Raw mode stops the terminal turning
+	// Ctrl+C into a signal.
+	quitInput = "ctrl+c"
+)
+
+// Options left unset are taken from the ROM's settings, then the defaults.
+type Options struct {
+	frontend.Settings
+
+	// KeyRelease is how long a key stays down after the terminal last sent
+	// it, since terminals don't report releases. It defaults to
+	// DefaultKeyRelease.
+	KeyRelease time.Duration
+
+	// Bell rings the terminal bell each time the sound timer starts.
+	// Otherwise the session is silent.
+	Bell bool
+}
+
+func (o *Options) applySettings(settings *database.Entry) {
+	o.Settings.Apply(settings)
+
+	if o.KeyRelease == 0 {
+		o.KeyRelease = DefaultKeyRelease
+	}
+}
+
+// escapes are the sequences terminals send for the arrow keys, in normal and
+// application cursor mode.
+var escapes = map[string]string{
+	"\x1b[A": "up",
+	"\x1b[B": "down",
+	"\x1b[C": "right",
+	"\x1b[D": "left",
+	"\x1bOA": "up",
+	"\x1bOB": "down",
+	"\x1bOC": "right",
+	"\x1bOD": "left",
+}
+
+// inputs splits what the terminal sent into the keymap names of the keys
+// pressed. Letters are lowercased, as the keymap ignores shift.
+func inputs(b []byte) []string {
+	var names []string
+
+	for len(b) > 0 {
+		if b[0] == 0x1b {
+			matched := false
+
+			for seq, name := range escapes {
+				if bytes.HasPrefix(b, []byte(seq)) {
+					names = append(names, name)
+					b = b[len(seq):]
+					matched = true
+
+					break
+				}
+			}
+
+			if !matched {
+				names = append(names, "escape")
+				b = b[1:]
+			}
+
+			continue
+		}
+
+		switch c := b[0]; {
+		case c == 0x03:
+			names = append(names, quitInput)
+		case c == ' ':
+			names = append(names, "space")
+		case c == '\r' || c == '\n':
+			names = append(names, "return")
+		case c == '\t':
+			names = append(names, "tab")
+		case c == 0x7f:
+			names = append(names, "backspace")
+		case c > ' ' && c < 0x7f:
+			names = append(names, strings.ToLower(string(c)))
+		}
+
+		b = b[1:]
+	}
+
+	return names
+}
+
+// keys holds each key down until KeyRelease has passed since the terminal
+// last sent it. Holding a key makes the terminal repeat it, which keeps it
+// down.
+type keys struct {
+	bindings map[string]uint8
+	timeout  time.Duration
+
+	pressed [16]time.Time
+
+	current  [16]bool
+	previous [16]bool
+}
+
+// inputKeys are the terminal keys bound to the inputs a ROM's settings name.
+var inputKeys = map[string]string{
+	"up":    "up",
+	"down":  "down",
+	"left":  "left",
+	"right": "right",
+	"a":     "space",
+	"b":     "return",
+}
+
+// newKeys binds the keypad to layout, and the inputs in the ROM's settings to
+// inputKeys.
+func newKeys(layout keymap.Layout, inputs map[string]uint8, timeout time.Duration) (*keys, error) {
+	bindings, err := layout.Bindings()
+	if err != nil {
+		return nil, err
+	}
+
+	for name, key := range inputs {
+		if host, ok := inputKeys[name]; ok && key < 16 {
+			bindings[host] = key
+		}
+	}
+
+	return &keys{bindings: bindings, timeout: timeout}, nil
+}
+
+func (k *keys) press(name string, now time.Time) {
+	if key, ok := k.bindings[name]; ok {
+		k.pressed[key] = now
+	}
+}
+
+// update works out which keys are down at the start of a frame.
+func (k *keys) update(now time.Time) {
+	k.previous = k.current
+
+	for i, t := range k.pressed {
+		k.current[i] = !t.IsZero() && now.Sub(t) < k.timeout
+	}
+}
+
+func (k *keys) IsKeyDown(i uint8) bool {
+	return k.current[i&0xF]
+}
+
+func (k *keys) WasKeyReleased(i uint8) bool {
+	return !k.current[i&0xF] && k.previous[i&0xF]
+}
+
+// halfBlocks are indexed by whether the top and bottom pixels of a cell are
+// set.
+var halfBlocks = [2][2]string{
+	{" ", "▄"},
+	{"▀", "█"},
+}
+
+// screen draws the framebuffer once a frame if it has changed. Any set pixel
+// is drawn, whichever XO-CHIP planes it is on.
+type screen struct {
+	w *bufio.Writer
+
+	pixels [][]uint8
+	dirty  bool
+}
+
+func (s *screen) Draw(pixels [][]uint8) error {
+	if len(pixels) != len(s.pixels) {
+		// Clear what the other resolution left behind.
+		_, _ = s.w.WriteString("\x1b[2J")
+	}
+
+	s.pixels = make([][]uint8, len(pixels))
+	for y := range pixels {
+		s.pixels[y] = append([]uint8(nil), pixels[y]...)
+	}
+
+	s.dirty = true
+
+	return nil
+}
+
+func (s *screen) present() error {
+	if s.dirty {
+		s.dirty = false
+
+		_, _ = s.w.WriteString("\x1b[H")
+
+		for y := 0; y < len(s.pixels); y += 2 {
+			for x := range s.pixels[y] {
+				top := s.pixels[y][x] != 0
+				bottom := y+1 < len(s.pixels) && s.pixels[y+1][x] != 0
+
+				_, _ = s.w.WriteString(halfBlocks[b2i(top)][b2i(bottom)])
+			}
+
+			_, _ = s.w.WriteString("\r\n")
+		}
+	}
+
+	if err := s.w.Flush(); err != nil {
+		return fmt.Errorf("failed to draw screen: %v", err)
+	}
+
+	return nil
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// beeper rings the bell, if enabled, when the sound starts.
+type beeper struct {
+	w    io.Writer
+	bell bool
+	on   bool
+}
+
+func (b *beeper) SetSound(on bool) {
+	if on && !b.on && b.bell {
+		_, _ = b.w.Write([]byte{'\a'})
+	}
+
+	b.on = on
+}
+
+// readInput sends what is typed on r to input until r fails.
+func readInput(r io.Reader, input chan<- []byte) {
+	defer close(input)
+
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			input <- append([]byte(nil), buf[:n]...)
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Run plays the ROM in filename on the terminal until the program exits or
+// Ctrl+C is pressed. The RPL flags are kept next to the ROM, as in the SDL
+// frontend.
+func Run(filename string, options Options) error {
+	rom, settings, err := romfile.LoadWithSettings(filename, options.Database)
+	if err != nil {
+		return err
+	}
+
+	options.applySettings(settings)
+
+	layout, err := options.KeypadLayout(rom)
+	if err != nil {
+		return err
+	}
+
+	var romKeys map[string]uint8
+	if settings != nil {
+		romKeys = settings.Keys
+	}
+
+	keys, err := newKeys(layout, romKeys, options.KeyRelease)
+	if err != nil {
+		return err
+	}
+
+	seed := options.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	screen := &screen{w: out}
+	beeper := &beeper{w: out, bell: options.Bell}
+
+	c, err := chip8.New(keys, beeper, screen, *options.Quirks, chip8.NewRandom(rand.NewSource(seed)))
+	if err != nil {
+		return fmt.Errorf("failed to init chip8: %v", err)
+	}
+
+	if err := c.LoadROMAt(bytes.NewReader(rom), options.LoadAddress); err != nil {
+		return fmt.Errorf("failed to load ROM file: %v", err)
+	}
+
+	flags, err := frontend.LoadRPLFlags(filename)
+	if err != nil {
+		return err
+	}
+
+	c.SetRPLFlags(flags)
+
+	// finish saves the flags when the session ends normally.
+	finish := func() error {
+		if c.RPLFlags() == flags {
+			return nil
+		}
+
+		return frontend.SaveRPLFlags(filename, c.RPLFlags())
+	}
+
+	restore, err := makeRaw(os.Stdin.Fd())
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	// Hide the cursor while playing, and put it back below the screen after.
+	_, _ = out.WriteString("\x1b[?25l\x1b[2J")
+	defer func() {
+		_, _ = out.WriteString("\x1b[?25h\r\n")
+		_ = out.Flush()
+	}()
+
+	input := make(chan []byte, 16)
+	go readInput(os.Stdin, input)
+
+	ticker := time.NewTicker(time.Second / framesPerSecond)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+	read:
+		for {
+			select {
+			case b, ok := <-input:
+				if !ok {
+					return finish()
+				}
+
+				for _, name := range inputs(b) {
+					if name == quitInput {
+						return finish()
+					}
+
+					keys.press(name, now)
+				}
+			default:
+				break read
+			}
+		}
+
+		keys.update(now)
+
+		if err := c.RunFrame(options.CyclesPerFrame); err != nil {
+			return fmt.Errorf("failed to run frame: %v", err)
+		}
+
+		if err := screen.present(); err != nil {
+			return err
+		}
+
+		if c.Halted() {
+			return finish()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package tty
+
+import (
+	"bufio"
+	"bytes"
+	"chip8/keymap"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TTYSuite struct {
+	suite.Suite
+}
+
+func (suite *TTYSuite) TestInputs() {
+	suite.Assert().Equal([]string{"q", "up", "w", "left", "space", "return", quitInput}, inputs([]byte("Q\x1b[Aw\x1bOD \r\x03")))
+	suite.Assert().Equal([]string{"escape", "1"}, inputs([]byte("\x1b1")))
+}
+
+func (suite *TTYSuite) TestKeyRelease() {
+	k, err := newKeys(keymap.Layouts[keymap.DefaultLayout], nil, 100*time.Millisecond)
+	suite.Require().Nil(err)
+
+	start := time.Now()
+
+	// W is key 5 on the default layout.
+	k.press("w", start)
+	k.update(start)
+	suite.Assert().True(k.IsKeyDown(5))
+
+	// A repeat from the terminal keeps the key down past the timeout.
+	k.press("w", start.Add(80*time.Millisecond))
+	k.update(start.Add(150 * time.Millisecond))
+	suite.Assert().True(k.IsKeyDown(5))
+	suite.Assert().False(k.WasKeyReleased(5))
+
+	k.update(start.Add(200 * time.Millisecond))
+	suite.Assert().False(k.IsKeyDown(5))
+	suite.Assert().True(k.WasKeyReleased(5))
+
+	later := start.Add(time.Second)
+	k.press("p", later)
+	k.update(later)
+	for i := uint8(0); i < 16; i++ {
+		suite.Assert().False(k.IsKeyDown(i))
+	}
+}
+
+func (suite *TTYSuite) TestROMInputs() {
+	k, err := newKeys(keymap.Layouts[keymap.DefaultLayout], map[string]uint8{"up": 5, "a": 6, "start": 7}, time.Second)
+	suite.Require().Nil(err)
+
+	now := time.Now()
+	k.press("up", now)
+	k.press("space", now)
+	k.update(now)
+
+	suite.Assert().True(k.IsKeyDown(5))
+	suite.Assert().True(k.IsKeyDown(6))
+	suite.Assert().False(k.IsKeyDown(7))
+}
+
+func (suite *TTYSuite) TestScreen() {
+	var b bytes.Buffer
+	s := &screen{w: bufio.NewWriter(&b)}
+
+	suite.Require().Nil(s.Draw([][]uint8{
+		{1, 0, 1, 0},
+		{1, 1, 0, 0},
+		{0, 0, 0, 2},
+	}))
+	suite.Require().Nil(s.present())
+	suite.Assert().Equal("\x1b[2J\x1b[H█▄▀ \r\n   ▀\r\n", b.String())
+
+	// Nothing is redrawn until the framebuffer changes.
+	b.Reset()
+	suite.Require().Nil(s.present())
+	suite.Assert().Equal("", b.String())
+}
+
+func (suite *TTYSuite) TestBell() {
+	var b bytes.Buffer
+
+	quiet := &beeper{w: &b}
+	quiet.SetSound(true)
+	suite.Assert().Equal("", b.String())
+
+	loud := &beeper{w: &b, bell: true}
+	loud.SetSound(true)
+	loud.SetSound(true)
+	loud.SetSound(false)
+	loud.SetSound(true)
+	suite.Assert().Equal("\a\a", b.String())
+}
+
+func TestTTYSuite(t *testing.T) {
+	suite.Run(t, new(TTYSuite))
+}
@@ -0,0 +1,114 @@
+// Package wav writes unsigned 8-bit mono PCM audio as a WAV file.
+package wav
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+const headerSize = 44
+
+// Encoder writes a WAV header followed by the samples passed to Write. The
+// header's sizes are filled in by Close if the underlying writer is an
+// io.WriteSeeker; otherwise they are left at their maximum, which players
+// read as "until the end of the file".
+type Encoder struct {
+	w          io.Writer
+	sampleRate int
+	size       uint32
+}
+
+// NewEncoder writes the header for sampleRate samples per second to w.
+func NewEncoder(w io.Writer, sampleRate int) (*Encoder, error) {
+	e := &Encoder{w: w, sampleRate: sampleRate}
+
+	if err := e.writeHeader(math.MaxUint32, math.MaxUint32); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (e *Encoder) writeHeader(riffSize, dataSize uint32) error {
+	header := struct {
+		RIFF          [4]byte
+		RIFFSize      uint32
+		WAVE          [4]byte
+		Fmt           [4]byte
+		FmtSize       uint32
+		Format        uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+		Data          [4]byte
+		DataSize      uint32
+	}{
+		RIFF:          [4]byte{'R', 'I', 'F', 'F'},
+		RIFFSize:      riffSize,
+		WAVE:          [4]byte{'W', 'A', 'V', 'E'},
+		Fmt:           [4]byte{'f', 'm', 't', ' '},
+		FmtSize:       16,
+		Format:        1, // PCM
+		Channels:      1,
+		SampleRate:    uint32(e.sampleRate),
+		ByteRate:      uint32(e.sampleRate),
+		BlockAlign:    1,
+		BitsPerSample: 8,
+		Data:          [4]byte{'d', 'a', 't', 'a'},
+		DataSize:      dataSize,
+	}
+
+	if err := binary.Write(e.w, binary.LittleEndian, &header); err != nil {
+		return fmt.Errorf("failed to write WAV header: %v", err)
+	}
+
+	return nil
+}
+
+// Write writes unsigned 8-bit samples.
+func (e *Encoder) Write(samples []byte) (int, error) {
+	n, err := e.w.Write(samples)
+	e.size += uint32(n)
+
+	return n, err
+}
+
+// Close pads the data to an even length and fills in the header. It does not
+// close the underlying writer.
+func (e *Encoder) Close() error {
+	if e.size%2 != 0 {
+		if _, err := e.w.Write([]byte{0x80}); err != nil {
+			return fmt.Errorf("failed to write WAV padding: %v", err)
+		}
+	}
+
+	ws, ok := e.w.(io.WriteSeeker)
+	if !ok {
+		return nil
+	}
+
+	end, err := ws.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("failed to seek to WAV header: %v", err)
+	}
+
+	padded := e.size + e.size%2
+
+	if _, err := ws.Seek(end-headerSize-int64(padded), io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to WAV header: %v", err)
+	}
+
+	if err := e.writeHeader(padded+headerSize-8, e.size); err != nil {
+		return err
+	}
+
+	if _, err := ws.Seek(end, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to end of WAV: %v", err)
+	}
+
+	return nil
+}
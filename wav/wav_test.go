@@ -0,0 +1,62 @@
+package wav_test
+
+import (
+	"bytes"
+	"chip8/wav"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type EncoderSuite struct {
+	suite.Suite
+}
+
+func (suite *EncoderSuite) TestHeader() {
+	f, err := ioutil.TempFile("", "wav")
+	suite.Require().Nil(err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	e, err := wav.NewEncoder(f, 22050)
+	suite.Require().Nil(err)
+
+	_, err = e.Write([]byte{0x80, 0xFF, 0x00})
+	suite.Require().Nil(err)
+	suite.Require().Nil(e.Close())
+
+	b, err := ioutil.ReadFile(f.Name())
+	suite.Require().Nil(err)
+	suite.Require().Len(b, 48)
+
+	suite.Assert().Equal("RIFF", string(b[0:4]))
+	suite.Assert().Equal(uint32(40), binary.LittleEndian.Uint32(b[4:]))
+	suite.Assert().Equal("WAVEfmt ", string(b[8:16]))
+	suite.Assert().Equal(uint16(1), binary.LittleEndian.Uint16(b[22:]))
+	suite.Assert().Equal(uint32(22050), binary.LittleEndian.Uint32(b[24:]))
+	suite.Assert().Equal(uint16(8), binary.LittleEndian.Uint16(b[34:]))
+	suite.Assert().Equal("data", string(b[36:40]))
+	suite.Assert().Equal(uint32(3), binary.LittleEndian.Uint32(b[40:]))
+	suite.Assert().Equal([]byte{0x80, 0xFF, 0x00, 0x80}, b[44:])
+}
+
+func (suite *EncoderSuite) TestStream() {
+	var b bytes.Buffer
+
+	e, err := wav.NewEncoder(&b, 8000)
+	suite.Require().Nil(err)
+
+	_, err = e.Write([]byte{0x80, 0x80})
+	suite.Require().Nil(err)
+	suite.Require().Nil(e.Close())
+
+	suite.Assert().Equal(46, b.Len())
+	suite.Assert().Equal(uint32(0xFFFFFFFF), binary.LittleEndian.Uint32(b.Bytes()[40:]))
+}
+
+func TestEncoderSuite(t *testing.T) {
+	suite.Run(t, new(EncoderSuite))
+}